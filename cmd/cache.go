@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"runtime"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/litteratum/git-workon/internal/app"
+	"github.com/spf13/cobra"
+)
+
+func buildCacheCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the projects cache",
+		Long: `The cache remembers the source every project was cloned from and its usage history.
+It is kept per workspace.`,
+	}
+
+	cmd.AddCommand(
+		buildCacheListCommand(),
+		buildCacheForgetCommand(),
+		buildCacheSetSourceCommand(),
+		buildCachePruneCommand(),
+		buildCacheExportCommand(),
+		buildCacheImportCommand(),
+	)
+	return cmd
+}
+
+func buildCacheListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List cached projects",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cache, err := newCache()
+			if err != nil {
+				return err
+			}
+
+			projects := cache.List()
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tSOURCE\tCLONED\tOPENED\tDONE")
+			for _, name := range slices.Sorted(maps.Keys(projects)) {
+				info := projects[name]
+				source := info.Source
+				if source == "" {
+					source = info.URL
+				}
+				fmt.Fprintf(
+					w,
+					"%s\t%s\t%s\t%s\t%s\n",
+					name,
+					orDash(source),
+					formatTime(info.ClonedAt),
+					formatTime(info.LastOpenedAt),
+					formatTime(info.LastDoneAt),
+				)
+			}
+			return w.Flush()
+		},
+		SilenceUsage: true,
+	}
+}
+
+func buildCacheForgetCommand() *cobra.Command {
+	var directory string
+
+	return &cobra.Command{
+		Use:   "forget <project>...",
+		Short: "Remove projects from the cache",
+		Long:  `Remove projects from the cache. The projects themselves are left untouched.`,
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cache, err := newCache()
+			if err != nil {
+				return err
+			}
+			return app.ForgetProjects(cache, args)
+		},
+		SilenceUsage:      true,
+		ValidArgsFunction: completeKnownProjects(&directory),
+	}
+}
+
+func buildCacheSetSourceCommand() *cobra.Command {
+	var directory string
+
+	return &cobra.Command{
+		Use:   "set-source <project> <source>",
+		Short: "Change the cached source of a project",
+		Long: `Change the source the project is cloned from by "gw go", e.g. after the repository was moved.
+The source has the same format as the configured ones.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cache, err := newCache()
+			if err != nil {
+				return err
+			}
+			return app.SetProjectSource(cache, args[0], args[1])
+		},
+		SilenceUsage:      true,
+		ValidArgsFunction: completeKnownProjects(&directory),
+	}
+}
+
+func buildCachePruneCommand() *cobra.Command {
+	var (
+		directory string
+		jobs      int
+		dryRun    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Drop projects which cannot be cloned again",
+		Long: `Drop cached projects which are not in the working directory and have no source,
+whose source is not configured anymore or whose repository is unreachable.
+Repositories are checked concurrently, at most -j/--jobs at a time.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wd, err := newWorkingDir(&directory)
+			if err != nil {
+				return err
+			}
+
+			verb := "pruned"
+			if dryRun {
+				verb = "would be pruned"
+			}
+			pruned, err := wd.PruneCache(app.PruneOpts{Jobs: jobs, DryRun: dryRun})
+			if err != nil {
+				return err
+			}
+			for _, project := range pruned {
+				fmt.Printf("%s: %s (%s)\n", project.Project, verb, project.Reason)
+			}
+			return nil
+		},
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().StringVarP(&directory, "directory", "d", "", "working directory")
+	cmd.Flags().IntVarP(&jobs, "jobs", "j", runtime.NumCPU(), "number of repositories to check concurrently")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only show what would be pruned")
+
+	return cmd
+}
+
+func buildCacheExportCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "export [<file>]",
+		Short: "Export the cache",
+		Long:  `Write the cached projects to the file or to the standard output, e.g. to import them on another machine.`,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cache, err := newCache()
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 0 || args[0] == "-" {
+				return app.ExportCache(cache, os.Stdout)
+			}
+			f, err := os.Create(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to create \"%s\": %s", args[0], err)
+			}
+			if err = app.ExportCache(cache, f); err != nil {
+				f.Close()
+				return err
+			}
+			return f.Close()
+		},
+		SilenceUsage: true,
+	}
+}
+
+func buildCacheImportCommand() *cobra.Command {
+	var overwrite bool
+
+	cmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import projects to the cache",
+		Long: `Add the projects exported by "gw cache export" (or any projects.json) to the cache.
+Use "-" to read from the standard input. Cached projects are kept unless --overwrite is passed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cache, err := newCache()
+			if err != nil {
+				return err
+			}
+
+			var r io.Reader = os.Stdin
+			if args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return fmt.Errorf("failed to open \"%s\": %s", args[0], err)
+				}
+				defer f.Close()
+				r = f
+			}
+			imported, err := app.ImportCache(cache, r, overwrite)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("imported %d project(s)\n", len(imported))
+			return nil
+		},
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().BoolVar(&overwrite, "overwrite", false, "replace the cached projects with the imported ones")
+
+	return cmd
+}
+
+func newCache() (app.ICache, error) {
+	config, err := loadConfig("")
+	if err != nil {
+		return nil, err
+	}
+	cache, err := app.NewCacheFromFile(config.Workspace)
+	if err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.DateTime)
+}
+
+func init() {
+	rootCmd.AddCommand(buildCacheCommand())
+}
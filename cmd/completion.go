@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type completionFunc func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective)
+
+func completeKnownProjects(directory *string) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		wd, err := newWorkingDir(directory)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return wd.KnownProjects(), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func completeProjects(directory *string) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		wd, err := newWorkingDir(directory)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		projects, err := wd.Projects()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return projects, cobra.ShellCompDirectiveNoFileComp
+	}
+}
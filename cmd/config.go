@@ -1,21 +1,265 @@
 package cmd
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"strings"
 
 	"github.com/litteratum/git-workon/internal/app"
 	"github.com/spf13/cobra"
 )
 
-var configCmd = &cobra.Command{
-	Use:   "config",
-	Short: "Show the current config",
-	Run: func(cmd *cobra.Command, args []string) {
-		config := app.LoadConfig()
-		fmt.Println(config)
-	},
+func buildConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Show or manage the configuration",
+		Long: `Show the effective configuration merged from all layers.
+Use the subcommands to create, change and check the user configuration.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := loadConfig("")
+			if err != nil {
+				return err
+			}
+			fmt.Println(config)
+			return nil
+		},
+		SilenceUsage: true,
+	}
+
+	cmd.AddCommand(
+		buildConfigInitCommand(),
+		buildConfigGetCommand(),
+		buildConfigSetCommand(),
+		buildConfigUnsetCommand(),
+		buildConfigEditCommand(),
+		buildConfigValidateCommand(),
+	)
+	return cmd
+}
+
+func buildConfigInitCommand() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Create the configuration interactively",
+		Long: `Ask for the working directory, the editor and the sources and write them
+to the user configuration (or to the file given by --config or GW_CONFIG).`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := app.UserConfigPath(configPath)
+			if _, err := os.Stat(path); err == nil && !force {
+				return fmt.Errorf("%s already exists. Use --force to overwrite it", path)
+			}
+
+			defaults := app.NewDefaultConfig()
+			if editor := os.Getenv("EDITOR"); editor != "" {
+				defaults.Editor = editor
+			}
+			reader := bufio.NewReader(cmd.InOrStdin())
+			out := cmd.OutOrStdout()
+
+			dir, err := prompt(reader, out, "Working directory", defaults.Dir)
+			if err != nil {
+				return err
+			}
+			editor, err := prompt(reader, out, "Editor", defaults.Editor)
+			if err != nil {
+				return err
+			}
+			sources, err := prompt(reader, out, "Sources (comma-separated, e.g. git@github.com:<username>)", "")
+			if err != nil {
+				return err
+			}
+
+			file := app.NewConfigFile(path)
+			if err = file.Set("dir", []string{dir}, false); err != nil {
+				return err
+			}
+			if err = file.Set("editor", []string{editor}, false); err != nil {
+				return err
+			}
+			if err = file.Set("sources", splitSources(sources), false); err != nil {
+				return err
+			}
+			if err = file.Save(); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "configuration written to %s\n", path)
+			return nil
+		},
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "overwrite the existing configuration")
+
+	return cmd
+}
+
+func buildConfigGetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print a configuration value",
+		Long: `Print the effective value of the key, e.g. "dir", "sources" or "clone.depth".
+Lists are printed one item per line, objects as JSON.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := loadConfig("")
+			if err != nil {
+				return err
+			}
+			value, err := app.ConfigValue(config, args[0])
+			if err != nil {
+				return err
+			}
+			return printConfigValue(cmd.OutOrStdout(), value)
+		},
+		SilenceUsage: true,
+	}
+}
+
+func buildConfigSetCommand() *cobra.Command {
+	var appendValues bool
+
+	cmd := &cobra.Command{
+		Use:   "set <key> <value>...",
+		Short: "Set a configuration value",
+		Long: `Set the key in the user configuration (or in the file given by --config or GW_CONFIG).
+List keys, e.g. "sources", take several values. Use --append to add them to the existing ones.
+Quote map keys containing dots, e.g. 'source_settings."git@github.com:me".clone.depth'.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := app.OpenConfigFile(app.UserConfigPath(configPath))
+			if err != nil {
+				return err
+			}
+			if err = file.Set(args[0], args[1:], appendValues); err != nil {
+				return err
+			}
+			return file.Save()
+		},
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().BoolVarP(&appendValues, "append", "a", false, "append the values to a list")
+
+	return cmd
+}
+
+func buildConfigUnsetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unset <key>",
+		Short: "Remove a configuration value",
+		Long:  `Remove the key from the user configuration (or from the file given by --config or GW_CONFIG).`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := app.OpenConfigFile(app.UserConfigPath(configPath))
+			if err != nil {
+				return err
+			}
+			if err = file.Unset(args[0]); err != nil {
+				return err
+			}
+			return file.Save()
+		},
+		SilenceUsage: true,
+	}
+}
+
+func buildConfigEditCommand() *cobra.Command {
+	var editor string
+
+	cmd := &cobra.Command{
+		Use:   "edit",
+		Short: "Open the configuration in the editor",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return app.EditConfig(app.ConfigOpts{Path: configPath, Workspace: workspace}, editor)
+		},
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().StringVarP(&editor, "editor", "e", "", "editor to use")
+
+	return cmd
+}
+
+func buildConfigValidateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "Check the configuration",
+		Long: `Check every configuration layer for unknown keys and wrong types,
+the working directory for being usable and the sources for being well-formed.
+Exits with a non-zero code if any problem was found.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			problems := app.ValidateConfig(app.ConfigOpts{Path: configPath, Workspace: workspace})
+			for _, problem := range problems {
+				fmt.Fprintln(cmd.OutOrStdout(), problem)
+			}
+			if len(problems) > 0 {
+				return fmt.Errorf("found %d problem(s)", len(problems))
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "the configuration is valid")
+			return nil
+		},
+		SilenceUsage: true,
+	}
+}
+
+func prompt(reader *bufio.Reader, out io.Writer, question, defaultValue string) (string, error) {
+	if defaultValue != "" {
+		fmt.Fprintf(out, "%s [%s]: ", question, defaultValue)
+	} else {
+		fmt.Fprintf(out, "%s: ", question)
+	}
+	answer, err := reader.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return defaultValue, nil
+	}
+	return answer, nil
+}
+
+func splitSources(value string) []string {
+	sources := []string{}
+	for _, source := range strings.Split(value, ",") {
+		if source = strings.TrimSpace(source); source != "" {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
+func printConfigValue(out io.Writer, value any) error {
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case map[string]any:
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(data))
+	case []any:
+		for _, item := range value {
+			if err := printConfigValue(out, item); err != nil {
+				return err
+			}
+		}
+	default:
+		fmt.Fprintln(out, value)
+	}
+	return nil
 }
 
 func init() {
-	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(buildConfigCommand())
 }
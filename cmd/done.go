@@ -1,6 +1,11 @@
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
 	"github.com/litteratum/git-workon/internal/app"
 	"github.com/spf13/cobra"
 )
@@ -9,30 +14,122 @@ func buildDoneCommand() *cobra.Command {
 	var (
 		directory string
 		force     bool
+		output    string
+		dryRun    bool
+		merged    bool
+		stale     string
 	)
 
 	cmd := &cobra.Command{
 		Use:   "done [<project>...]",
 		Short: "Finish the project",
-		Long:  `Remove the project(s) from the working directory`,
+		Long: `Remove the project(s) from the working directory.
+Exits with a non-zero code if any project was kept.
+Use --output json to get a machine-readable report.
+Use --dry-run to see what would be removed without removing anything.
+Use --detect-merged to treat commits which were rebased or squashed into the default branch of origin as pushed.
+Use --stale to finish only the projects not used for longer than the age (see "gw stale").
+A project which is not in the working directory is matched against the ones which are:
+close matches are offered to choose from if the standard input is a terminal and suggested otherwise.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			config := app.LoadConfig()
-			cache := app.NewCacheFromFile()
-			ensureDir(&directory, config.Dir)
-			wd := app.NewWorkingDir(directory, config, cache)
-			return wd.Done(
-				args,
-				app.DoneOpts{Force: force},
-			)
+			if output != "text" && output != "json" {
+				return fmt.Errorf("unknown output format \"%s\"", output)
+			}
+
+			opts := app.DoneOpts{
+				Force:        force,
+				DryRun:       dryRun,
+				DetectMerged: merged,
+				Choose:       newChooser("skip \"%s\""),
+			}
+			if stale != "" {
+				age, err := app.ParseAge(stale)
+				if err != nil {
+					return err
+				}
+				opts.Stale = age
+			}
+
+			newWD := newWorkingDir
+			if dryRun {
+				newWD = newDryRunWorkingDir
+			}
+			wd, err := newWD(&directory)
+			if err != nil {
+				return err
+			}
+			results, err := wd.Done(args, opts)
+
+			if output == "json" {
+				if results == nil {
+					results = []app.DoneResult{}
+				}
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if encodeErr := encoder.Encode(results); encodeErr != nil {
+					return encodeErr
+				}
+			} else {
+				if stale != "" && len(results) == 0 {
+					fmt.Println("no stale projects")
+				}
+				printDoneResults(results)
+			}
+			return err
 		},
-		SilenceUsage: true,
+		SilenceUsage:      true,
+		ValidArgsFunction: completeProjects(&directory),
 	}
 
 	cmd.Flags().StringVarP(&directory, "directory", "d", "", "working directory")
 	cmd.Flags().BoolVarP(&force, "force", "f", false, "force")
+	cmd.Flags().StringVar(&output, "output", "text", "output format: text or json")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the plan without removing anything")
+	cmd.Flags().BoolVar(&merged, "detect-merged", false, "treat commits merged upstream by rebase or squash as pushed")
+	cmd.Flags().StringVar(&stale, "stale", "", "only finish projects not used for longer than this age, e.g. 30d")
 
 	return cmd
 }
+
+func printDoneResults(results []app.DoneResult) {
+	for _, result := range results {
+		switch result.Outcome {
+		case app.DoneRemoved:
+			fmt.Printf("%s: removed\n", result.Project)
+		case app.DoneWouldRemove:
+			fmt.Printf("%s: would be removed\n", result.Project)
+			if !result.State.Clean() {
+				fmt.Println(indent(result.State.String()))
+			}
+		case app.DoneKeptDirty:
+			fmt.Printf("%s: kept, %s:\n", result.Project, result.Reason)
+			fmt.Println(indent(result.State.String()))
+		default:
+			fmt.Printf("%s: %s: %s\n", result.Project, result.Outcome, result.Reason)
+		}
+		printIgnored(result.Ignored)
+	}
+}
+
+func printIgnored(ignored []app.IgnoredItem) {
+	if len(ignored) == 0 {
+		return
+	}
+	lines := []string{"Ignored:"}
+	for _, item := range ignored {
+		lines = append(lines, fmt.Sprintf("  %s (%s)", item.Item, item.Reason))
+	}
+	fmt.Println(indent(strings.Join(lines, "\n")))
+}
+
+func indent(text string) string {
+	lines := strings.Split(strings.Trim(text, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = "  " + line
+	}
+	return strings.Join(lines, "\n")
+}
+
 func init() {
 	rootCmd.AddCommand(buildDoneCommand())
 }
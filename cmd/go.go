@@ -1,6 +1,10 @@
 package cmd
 
 import (
+	"fmt"
+	"runtime"
+	"strings"
+
 	"github.com/litteratum/git-workon/internal/app"
 	"github.com/spf13/cobra"
 )
@@ -11,10 +15,14 @@ func buildGoCommand() *cobra.Command {
 		directory string
 		sources   []string
 		editor    string
+		jobs      int
+		dryRun    bool
+		clone     app.CloneOpts
+		worktree  string
 	)
 
 	cmd := &cobra.Command{
-		Use:   "go <project>...",
+		Use:   "go [<owner>/]<project>...",
 		Short: "Start the project",
 		Long: `Clone the project (if needed) into the working directory.
 Sources from the configuration are used but may be extended by -s/--source.
@@ -23,35 +31,106 @@ Sources are resolved in the following order:
 	* Cached for the project
 	* Sources from the configuration
 
+A source is either a prefix the project is appended to (e.g. "git@github.com:pallets")
+or a template with {project}, {owner} and {host} placeholders
+(e.g. "git@gitlab.corp:{owner}/{project}.git"). The owner (and the host) are taken from
+the "[<host>/]<owner>/<project>" argument or the cache.
+
+A project may also be an alias from the configuration.
+
+A name which is neither in the working directory, nor cached, nor an alias is matched
+against them. If the standard input is a terminal, close matches are offered to choose from.
+Otherwise, they are suggested if the project cannot be cloned.
+
+Projects are cloned concurrently, at most -j/--jobs at a time.
+
+Use --depth, --filter, --single-branch and --no-tags for shallow and partial
+clones of big repositories. They extend the "clone" options of the configuration.
+Use "gw hydrate" to fetch the rest of such a project later.
+Use --recurse-submodules to clone submodules too.
+
+Use --worktree <branch> to add a worktree of the project checked out to the
+branch. The worktree is created next to the project as "<project>-<branch>".
+The branch is created from the current HEAD if it does not exist.
+
 Use -o/--open to open the project in the configured editor.
 Override the editor using -e/--editor.
+
+Use --dry-run to print the plan without cloning or opening anything.
 	`,
 		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			config := app.LoadConfig()
-			cache := app.NewCacheFromFile()
-			ensureDir(&directory, config.Dir)
-			wd := app.NewWorkingDir(directory, config, cache)
-			return wd.Go(
-				args,
-				sources,
-				editor,
-				app.GoOpts{
-					Open: open,
-				},
-			)
+			newWD := newWorkingDir
+			if dryRun {
+				newWD = newDryRunWorkingDir
+			}
+			wd, err := newWD(&directory)
+			if err != nil {
+				return err
+			}
+			opts := app.GoOpts{
+				Open:     open,
+				Jobs:     jobs,
+				Clone:    clone,
+				Worktree: worktree,
+				Choose:   newChooser("use \"%s\" as it is"),
+			}
+			if dryRun {
+				plan, err := wd.PlanGo(args, sources, editor, opts)
+				if err != nil {
+					return err
+				}
+				printGoPlan(plan)
+				return nil
+			}
+			return wd.Go(args, sources, editor, opts)
 		},
-		SilenceUsage: true,
+		SilenceUsage:      true,
+		ValidArgsFunction: completeKnownProjects(&directory),
 	}
 
 	cmd.Flags().BoolVarP(&open, "open", "o", false, "open the project in the configured editor")
 	cmd.Flags().StringVarP(&directory, "directory", "d", "", "working directory")
 	cmd.Flags().StringSliceVarP(&sources, "source", "s", []string{}, "additional sources")
 	cmd.Flags().StringVarP(&editor, "editor", "e", "", "editor to use")
+	cmd.Flags().IntVarP(&jobs, "jobs", "j", runtime.NumCPU(), "number of projects to clone concurrently")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the plan without touching the disk")
+	cmd.Flags().IntVar(&clone.Depth, "depth", 0, "create a shallow clone with the given number of commits")
+	cmd.Flags().StringVar(&clone.Filter, "filter", "", "create a partial clone, e.g. blob:none or tree:0")
+	cmd.Flags().BoolVar(&clone.SingleBranch, "single-branch", false, "clone only the default branch")
+	cmd.Flags().BoolVar(&clone.NoTags, "no-tags", false, "do not clone tags")
+	cmd.Flags().BoolVar(&clone.RecurseSubmodules, "recurse-submodules", false, "clone submodules too")
+	cmd.Flags().StringVar(&worktree, "worktree", "", "add a worktree of the project for the given branch")
 
 	return cmd
 }
 
+func printGoPlan(plan app.GoPlan) {
+	for _, project := range plan.Projects {
+		switch {
+		case project.Err != nil:
+			fmt.Printf("%s: error: %s\n", project.Project, project.Err)
+		case project.Exists:
+			fmt.Printf("%s: already exists at %s\n", project.Project, project.Path)
+		default:
+			fmt.Printf("%s: would be cloned to %s trying:\n", project.Project, project.Path)
+			for i, url := range project.URLs {
+				fmt.Printf("  %d. %s\n", i+1, url)
+			}
+		}
+		if project.Worktree != "" {
+			fmt.Printf("%s: would add a worktree at %s\n", project.Project, project.Worktree)
+		}
+	}
+	if plan.OpenPath != "" {
+		fmt.Printf(
+			"would open %s with the first available editor of: %s\n",
+			plan.OpenPath,
+			strings.Join(plan.Editors, ", "),
+		)
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(buildGoCommand())
 }
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func buildHydrateCommand() *cobra.Command {
+	var directory string
+
+	cmd := &cobra.Command{
+		Use:   "hydrate <project>...",
+		Short: "Fetch the full history of the project",
+		Long: `Turn a shallow, partial or single-branch clone into a full one:
+unshallow the history, backfill missing objects and fetch all branches and tags.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wd, err := newWorkingDir(&directory)
+			if err != nil {
+				return err
+			}
+			return wd.Hydrate(args)
+		},
+		SilenceUsage:      true,
+		ValidArgsFunction: completeProjects(&directory),
+	}
+
+	cmd.Flags().StringVarP(&directory, "directory", "d", "", "working directory")
+
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(buildHydrateCommand())
+}
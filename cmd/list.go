@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/litteratum/git-workon/internal/app"
+	"github.com/spf13/cobra"
+)
+
+func buildListCommand() *cobra.Command {
+	var directory string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List projects",
+		Long: `List projects of the working directory with their state.
+A project is "clean" when it has nothing unpublished and may be safely done.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wd, err := newWorkingDir(&directory)
+			if err != nil {
+				return err
+			}
+			summaries, err := wd.List()
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tSOURCE\tBRANCH\tSTATE")
+			for _, s := range summaries {
+				if s.Err != nil {
+					log.Println(s.Err)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Name, s.Source, s.Branch, projectStateMarker(s))
+			}
+			return w.Flush()
+		},
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().StringVarP(&directory, "directory", "d", "", "working directory")
+
+	return cmd
+}
+
+func projectStateMarker(s app.ProjectSummary) string {
+	if s.Err != nil {
+		return "error"
+	}
+	if s.Clean {
+		return "clean"
+	}
+	return "dirty"
+}
+
+func init() {
+	rootCmd.AddCommand(buildListCommand())
+}
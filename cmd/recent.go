@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/litteratum/git-workon/internal/app"
+	"github.com/spf13/cobra"
+)
+
+func buildRecentCommand() *cobra.Command {
+	var (
+		directory string
+		limit     int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "recent",
+		Short: "List recently used projects",
+		Long: `List projects starting from the most recently cloned, opened or done one.
+Done projects are listed too: "gw go" clones them back onto the branch they were left on.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wd, err := newWorkingDir(&directory)
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tLAST USED\tOPENED\tBRANCH\tSTATE")
+			for _, project := range wd.Recent(limit) {
+				fmt.Fprintf(
+					w,
+					"%s\t%s\t%d\t%s\t%s\n",
+					project.Name,
+					project.LastUsed().Local().Format(time.DateTime),
+					project.OpenCount,
+					orDash(project.Branch),
+					recentStateMarker(project),
+				)
+			}
+			return w.Flush()
+		},
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().StringVarP(&directory, "directory", "d", "", "working directory")
+	cmd.Flags().IntVarP(&limit, "limit", "n", 10, "number of projects to list, 0 for all")
+
+	return cmd
+}
+
+func recentStateMarker(project app.RecentProject) string {
+	if project.Present {
+		return "present"
+	}
+	if !project.LastDoneAt.IsZero() {
+		return "done"
+	}
+	return "missing"
+}
+
+func init() {
+	rootCmd.AddCommand(buildRecentCommand())
+}
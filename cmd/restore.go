@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func buildRestoreCommand() *cobra.Command {
+	var directory string
+
+	cmd := &cobra.Command{
+		Use:   "restore <project>...",
+		Short: "Restore a done project",
+		Long: `Move the project(s) from the trash back to where they were done from.
+The most recently done copy is restored.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wd, err := newWorkingDir(&directory)
+			if err != nil {
+				return err
+			}
+
+			for _, project := range args {
+				entry, err := wd.Restore(project)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%s: restored to %s\n", project, entry.OriginalPath)
+			}
+			return nil
+		},
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().StringVarP(&directory, "directory", "d", "", "working directory")
+
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(buildRestoreCommand())
+}
@@ -1,12 +1,20 @@
 package cmd
 
 import (
-	"log"
+	"bufio"
+	"fmt"
 	"os"
+	"strconv"
 
+	"github.com/litteratum/git-workon/internal/app"
 	"github.com/spf13/cobra"
 )
 
+var (
+	configPath string
+	workspace  string
+)
+
 var rootCmd = &cobra.Command{
 	Use:   "gw",
 	Short: "Tool for managing GIT projects",
@@ -19,15 +27,103 @@ gw done <project>
 `,
 }
 
-func ensureDir(directory *string, configDir string) {
+func loadConfig(directory string) (app.Config, error) {
+	return app.LoadConfig(app.ConfigOpts{Path: configPath, Directory: directory, Workspace: workspace})
+}
+
+func newWorkingDir(directory *string) (app.WorkingDir, error) {
+	config, err := loadConfig(*directory)
+	if err != nil {
+		return app.WorkingDir{}, err
+	}
+	cache, err := app.NewCacheFromFile(config.Workspace)
+	if err != nil {
+		return app.WorkingDir{}, err
+	}
+	if err = ensureDir(directory, config.Dir); err != nil {
+		return app.WorkingDir{}, err
+	}
+	return app.NewWorkingDir(*directory, config, cache), nil
+}
+
+// newDryRunWorkingDir is newWorkingDir which neither creates the working directory nor creates, migrates or backs up
+// the cache
+func newDryRunWorkingDir(directory *string) (app.WorkingDir, error) {
+	config, err := loadConfig(*directory)
+	if err != nil {
+		return app.WorkingDir{}, err
+	}
+	cache, err := app.ReadCacheFile(config.Workspace)
+	if err != nil {
+		return app.WorkingDir{}, err
+	}
+	if *directory == "" {
+		*directory = config.Dir
+	}
+	return app.NewWorkingDir(*directory, config, cache), nil
+}
+
+func ensureDir(directory *string, configDir string) error {
 	if *directory == "" {
 		*directory = configDir
 	}
 
 	err := os.Mkdir(*directory, 0755)
 	if err != nil && !os.IsExist(err) {
-		log.Fatalf("failed to create the directory \"%s\": %s", *directory, err)
+		return fmt.Errorf("failed to create the directory \"%s\": %s", *directory, err)
 	}
+	return nil
+}
+
+// newChooser returns a prompt for the project meant by an unknown name if the standard input is a terminal.
+// The none format describes keeping the name, e.g. "skip \"%s\""
+func newChooser(none string) app.ChooseFunc {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	if null, err := os.Stat(os.DevNull); err == nil && os.SameFile(info, null) {
+		return nil
+	}
+	reader := bufio.NewReader(os.Stdin)
+
+	return func(project string, matches []string) (string, error) {
+		fmt.Fprintf(os.Stderr, "\"%s\" is not known. Did you mean:\n", project)
+		for i, match := range matches {
+			fmt.Fprintf(os.Stderr, "  %d. %s\n", i+1, match)
+		}
+		fmt.Fprintf(os.Stderr, "  0. none, %s\n", fmt.Sprintf(none, project))
+		for {
+			answer, err := prompt(reader, os.Stderr, "Choose", "1")
+			if err != nil {
+				return "", err
+			}
+			choice, err := strconv.Atoi(answer)
+			if err == nil && choice >= 0 && choice <= len(matches) {
+				if choice == 0 {
+					return "", nil
+				}
+				return matches[choice-1], nil
+			}
+			fmt.Fprintf(os.Stderr, "enter a number from 0 to %d\n", len(matches))
+		}
+	}
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(
+		&configPath,
+		"config",
+		"",
+		"configuration file to use instead of the user one (may be set by GW_CONFIG)",
+	)
+	rootCmd.PersistentFlags().StringVarP(
+		&workspace,
+		"workspace",
+		"w",
+		"",
+		"workspace to use instead of the default one (may be set by GW_WORKSPACE)",
+	)
 }
 
 func Execute() {
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/litteratum/git-workon/internal/app"
+	"github.com/spf13/cobra"
+)
+
+func buildStaleCommand() *cobra.Command {
+	var (
+		directory string
+		olderThan string
+		output    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "stale",
+		Short: "List projects not used for a long time",
+		Long: `List projects of the working directory whose last commit, last fetch, last file modification
+and last opening by "gw go --open" are all older than --older-than, the least recently active first.
+Use "gw done --stale" to finish them.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "text" && output != "json" {
+				return fmt.Errorf("unknown output format \"%s\"", output)
+			}
+			age, err := app.ParseAge(olderThan)
+			if err != nil {
+				return err
+			}
+
+			wd, err := newWorkingDir(&directory)
+			if err != nil {
+				return err
+			}
+			stale, err := wd.Stale(age)
+			if err != nil {
+				return err
+			}
+
+			if output == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(stale)
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tLAST ACTIVE\tCOMMITTED\tFETCHED\tMODIFIED\tOPENED")
+			for _, project := range stale {
+				fmt.Fprintf(
+					w,
+					"%s\t%s\t%s\t%s\t%s\t%s\n",
+					project.Name,
+					formatTime(project.Activity.Last()),
+					formatTime(project.Activity.LastCommit),
+					formatTime(project.Activity.LastFetch),
+					formatTime(project.Activity.LastModified),
+					formatTime(project.Activity.LastOpened),
+				)
+			}
+			return w.Flush()
+		},
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().StringVarP(&directory, "directory", "d", "", "working directory")
+	cmd.Flags().StringVar(&olderThan, "older-than", "30d", "inactivity age, e.g. 30d or 8w")
+	cmd.Flags().StringVar(&output, "output", "text", "output format: text or json")
+
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(buildStaleCommand())
+}
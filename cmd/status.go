@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+func buildStatusCommand() *cobra.Command {
+	var directory string
+
+	cmd := &cobra.Command{
+		Use:   "status [<project>...]",
+		Short: "Show the status of projects",
+		Long: `Show the branch, upstream, ahead/behind counts, number of modified and untracked
+files, stashes and unpushed tags of the project(s).
+If no project is passed, all projects of the working directory are shown.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wd, err := newWorkingDir(&directory)
+			if err != nil {
+				return err
+			}
+			statuses, err := wd.Status(args)
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tBRANCH\tUPSTREAM\tAHEAD\tBEHIND\tMODIFIED\tUNTRACKED\tSTASHES\tTAGS")
+			for _, s := range statuses {
+				if s.Err != nil {
+					log.Println(s.Err)
+					fmt.Fprintf(w, "%s\terror\t\t\t\t\t\t\t\n", s.Name)
+					continue
+				}
+				fmt.Fprintf(
+					w,
+					"%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\n",
+					s.Name,
+					s.Branch,
+					orDash(s.Upstream),
+					aheadBehind(s.Upstream, s.Ahead),
+					aheadBehind(s.Upstream, s.Behind),
+					s.Modified,
+					s.Untracked,
+					s.Stashes,
+					s.UnpushedTags,
+				)
+			}
+			return w.Flush()
+		},
+		SilenceUsage:      true,
+		ValidArgsFunction: completeProjects(&directory),
+	}
+
+	cmd.Flags().StringVarP(&directory, "directory", "d", "", "working directory")
+
+	return cmd
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func aheadBehind(upstream string, count int) string {
+	if upstream == "" {
+		return "-"
+	}
+	return strconv.Itoa(count)
+}
+
+func init() {
+	rootCmd.AddCommand(buildStatusCommand())
+}
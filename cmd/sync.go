@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"text/tabwriter"
+
+	"github.com/litteratum/git-workon/internal/app"
+	"github.com/spf13/cobra"
+)
+
+func buildSyncCommand() *cobra.Command {
+	var (
+		directory     string
+		jobs          int
+		defaultBranch bool
+		output        string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "sync [<project>...]",
+		Short: "Fetch and fast-forward projects",
+		Long: `Fetch all remotes of the project(s) and fast-forward the current branch to its upstream.
+If no project is passed, all projects of the working directory are synced.
+Projects are synced concurrently, at most -j/--jobs at a time.
+
+The current branch is not touched if it has local changes or has diverged from the upstream.
+Use --default-branch to fast-forward the local default branch of origin as well.
+Exits with a non-zero code if any project failed to sync.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "text" && output != "json" {
+				return fmt.Errorf("unknown output format \"%s\"", output)
+			}
+
+			wd, err := newWorkingDir(&directory)
+			if err != nil {
+				return err
+			}
+			results, err := wd.Sync(args, app.SyncOpts{Jobs: jobs, DefaultBranch: defaultBranch})
+
+			if output == "json" {
+				if results == nil {
+					results = []app.SyncResult{}
+				}
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if encodeErr := encoder.Encode(results); encodeErr != nil {
+					return encodeErr
+				}
+			} else if printErr := printSyncResults(results); printErr != nil {
+				return printErr
+			}
+			return err
+		},
+		SilenceUsage:      true,
+		ValidArgsFunction: completeProjects(&directory),
+	}
+
+	cmd.Flags().StringVarP(&directory, "directory", "d", "", "working directory")
+	cmd.Flags().IntVarP(&jobs, "jobs", "j", runtime.NumCPU(), "number of projects to sync concurrently")
+	cmd.Flags().BoolVar(&defaultBranch, "default-branch", false, "fast-forward the default branch too")
+	cmd.Flags().StringVar(&output, "output", "text", "output format: text or json")
+
+	return cmd
+}
+
+func printSyncResults(results []app.SyncResult) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tBRANCH\tRESULT\tDETAILS")
+	for _, result := range results {
+		var details string
+		switch result.Outcome {
+		case app.SyncUpdated:
+			details = fmt.Sprintf("%d new commit(s)", result.Behind)
+		case app.SyncDiverged:
+			details = fmt.Sprintf("%d ahead, %d behind", result.Ahead, result.Behind)
+		case app.SyncUpToDate:
+			if result.Ahead > 0 {
+				details = fmt.Sprintf("%d ahead", result.Ahead)
+			}
+		default:
+			details = result.Reason
+		}
+		var defaultBranch string
+		switch {
+		case result.DefaultBranchUpdated:
+			defaultBranch = fmt.Sprintf("%s fast-forwarded", result.DefaultBranch)
+		case result.DefaultBranchDiverged:
+			defaultBranch = fmt.Sprintf("%s diverged", result.DefaultBranch)
+		}
+		if defaultBranch != "" {
+			if details != "" {
+				details += "; "
+			}
+			details += defaultBranch
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.Project, orDash(result.Branch), result.Outcome, details)
+	}
+	return w.Flush()
+}
+
+func init() {
+	rootCmd.AddCommand(buildSyncCommand())
+}
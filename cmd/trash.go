@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/litteratum/git-workon/internal/app"
+	"github.com/spf13/cobra"
+)
+
+func buildTrashCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trash",
+		Short: "Manage done projects",
+		Long: `Done projects are moved to the trash and may be restored with "gw restore".
+Projects older than "trash_days" from the configuration are purged automatically.`,
+	}
+
+	cmd.AddCommand(buildTrashListCommand())
+	cmd.AddCommand(buildTrashEmptyCommand())
+	return cmd
+}
+
+func buildTrashListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List projects in the trash",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := newTrash().List()
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "PROJECT\tREMOVED\tSOURCE\tORIGINAL PATH")
+			for _, entry := range entries {
+				fmt.Fprintf(
+					w,
+					"%s\t%s\t%s\t%s\n",
+					entry.Project,
+					entry.RemovedAt.Format(time.DateTime),
+					orDash(entry.Source),
+					entry.OriginalPath,
+				)
+			}
+			return w.Flush()
+		},
+		SilenceUsage: true,
+	}
+}
+
+func buildTrashEmptyCommand() *cobra.Command {
+	var olderThan string
+
+	cmd := &cobra.Command{
+		Use:   "empty",
+		Short: "Permanently remove projects from the trash",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var age time.Duration
+			if olderThan != "" {
+				var err error
+				age, err = app.ParseAge(olderThan)
+				if err != nil {
+					return err
+				}
+			}
+
+			purged, err := newTrash().Purge(age)
+			for _, entry := range purged {
+				fmt.Printf("%s: purged (removed at %s)\n", entry.Project, entry.RemovedAt.Format(time.DateTime))
+			}
+			return err
+		},
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().StringVar(&olderThan, "older-than", "", "only purge projects removed before this age, e.g. 7d")
+
+	return cmd
+}
+
+func newTrash() app.Trash {
+	return app.NewTrash(app.TrashDir, app.NewOSFileSystem(app.NewOSExec()))
+}
+
+func init() {
+	rootCmd.AddCommand(buildTrashCommand())
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/litteratum/git-workon/internal/app"
+	"github.com/spf13/cobra"
+)
+
+func buildWorkspaceCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "workspace",
+		Short: "Manage workspaces",
+		Long: `A workspace is a named set of the working directory, the sources and the editor,
+e.g. for work and open-source projects. Every workspace has its own projects cache.
+Select one with -w/--workspace or GW_WORKSPACE, otherwise "default_workspace" is used.`,
+	}
+
+	cmd.AddCommand(buildWorkspaceListCommand())
+	cmd.AddCommand(buildWorkspaceAddCommand())
+	cmd.AddCommand(buildWorkspaceRemoveCommand())
+	return cmd
+}
+
+func buildWorkspaceListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List workspaces",
+		Long:  `List the configured workspaces. The active one is marked with "*".`,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := loadConfig("")
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "\tNAME\tDIR\tEDITOR\tSOURCES")
+			for _, name := range slices.Sorted(maps.Keys(config.Workspaces)) {
+				workspace := config.Workspaces[name]
+				marker := ""
+				if name == config.Workspace {
+					marker = "*"
+				}
+				fmt.Fprintf(
+					w,
+					"%s\t%s\t%s\t%s\t%s\n",
+					marker,
+					name,
+					orDash(workspace.Dir),
+					orDash(workspace.Editor),
+					orDash(strings.Join(workspace.Sources, ",")),
+				)
+			}
+			return w.Flush()
+		},
+		SilenceUsage: true,
+	}
+}
+
+func buildWorkspaceAddCommand() *cobra.Command {
+	var (
+		added       app.Workspace
+		makeDefault bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "add <name>",
+		Short: "Add a workspace",
+		Long: `Add the workspace to the user configuration (or to the file given by --config or GW_CONFIG).
+The editor and the sources not given are taken from the top level of the configuration.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := app.OpenConfigFile(app.UserConfigPath(configPath))
+			if err != nil {
+				return err
+			}
+			if err = file.AddWorkspace(args[0], added, makeDefault); err != nil {
+				return err
+			}
+			return file.Save()
+		},
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().StringVarP(&added.Dir, "dir", "d", "", "working directory of the workspace")
+	cmd.Flags().StringVarP(&added.Editor, "editor", "e", "", "editor of the workspace")
+	cmd.Flags().StringSliceVarP(&added.Sources, "source", "s", nil, "sources of the workspace")
+	cmd.Flags().BoolVar(&makeDefault, "default", false, "make the workspace the default one")
+	cmd.MarkFlagRequired("dir")
+
+	return cmd
+}
+
+func buildWorkspaceRemoveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <name>",
+		Short: "Remove a workspace",
+		Long: `Remove the workspace from the user configuration (or from the file given by --config or GW_CONFIG).
+Its projects are left untouched.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := app.OpenConfigFile(app.UserConfigPath(configPath))
+			if err != nil {
+				return err
+			}
+			if err = file.RemoveWorkspace(args[0]); err != nil {
+				return err
+			}
+			return file.Save()
+		},
+		SilenceUsage: true,
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(buildWorkspaceCommand())
+}
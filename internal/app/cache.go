@@ -2,11 +2,18 @@ package app
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"log"
+	"maps"
 	"os"
 	"path"
 	"path/filepath"
-	"syscall"
+	"slices"
+	"sync"
+	"time"
 )
 
 var cachePath = path.Join(getCacheDir(), "projects.json")
@@ -14,97 +21,407 @@ var cachePath = path.Join(getCacheDir(), "projects.json")
 type ICache interface {
 	Get(project string) ProjectInfo
 	Set(project string, info ProjectInfo)
-	Write()
+	Delete(project string)
+	List() map[string]ProjectInfo
+	Write() error
 }
 
 type ProjectInfo struct {
-	Source string `json:"source"`
+	Source       string    `json:"source"`
+	Owner        string    `json:"owner,omitempty"`
+	Host         string    `json:"host,omitempty"`
+	URL          string    `json:"url,omitempty"`
+	Branch       string    `json:"branch,omitempty"`
+	ClonedAt     time.Time `json:"cloned_at,omitzero"`
+	LastOpenedAt time.Time `json:"last_opened_at,omitzero"`
+	LastDoneAt   time.Time `json:"last_done_at,omitzero"`
+	OpenCount    int       `json:"open_count,omitempty"`
 }
 
+// LastUsed returns the time the project was last cloned, opened or done.
+func (i ProjectInfo) LastUsed() time.Time {
+	last := i.ClonedAt
+	for _, t := range []time.Time{i.LastOpenedAt, i.LastDoneAt} {
+		if t.After(last) {
+			last = t
+		}
+	}
+	return last
+}
+
+// Cache keeps the projects info in memory. Write merges the projects changed since the last write into the file, so
+// concurrent invocations do not lose each other's changes.
 type Cache struct {
-	Data map[string]ProjectInfo
+	data  map[string]ProjectInfo
+	dirty map[string]bool
+	path  string
+	mu    sync.Mutex
+}
+
+const cacheVersion = 1
+
+// cacheFile is the on-disk format. Before versioning the file was a bare map of projects.
+type cacheFile struct {
+	Version  int                    `json:"version"`
+	Projects map[string]ProjectInfo `json:"projects"`
 }
 
-func (c Cache) Get(project string) ProjectInfo {
-	return c.Data[project]
+var (
+	cacheLockTimeout  = 10 * time.Second
+	cacheLockStaleAge = time.Minute
+)
+
+func (c *Cache) Get(project string) ProjectInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.data[project]
+}
+
+func (c *Cache) Set(project string, info ProjectInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[project] = info
+	c.dirty[project] = true
 }
 
-func (c Cache) Set(project string, info ProjectInfo) {
-	c.Data[project] = info
+func (c *Cache) Delete(project string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.data, project)
+	c.dirty[project] = true
 }
 
-func (c Cache) Write() {
-	data, err := json.MarshalIndent(c.Data, "", "  ")
+func (c *Cache) List() map[string]ProjectInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return maps.Clone(c.data)
+}
+
+func (c *Cache) Write() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.write()
+}
+
+// write must be called with the mutex held.
+func (c *Cache) write() error {
+	unlock, err := lockCacheFile(c.path)
 	if err != nil {
-		log.Printf("failed to marshal the cache: %s", err)
+		return &CacheError{Path: c.path, Op: "lock", Err: err}
 	}
+	defer unlock()
 
-	err = os.WriteFile(cachePath, data, 0644)
-	if err != nil {
-		log.Printf("failed to write the cache file at %s: %s", cachePath, err)
+	merged, _, err := readCacheData(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		merged = map[string]ProjectInfo{}
+	} else if err != nil {
+		log.Printf("%s. It will be overwritten", err)
+		merged = maps.Clone(c.data)
+	}
+	for project := range c.dirty {
+		if info, ok := c.data[project]; ok {
+			merged[project] = info
+		} else {
+			delete(merged, project)
+		}
 	}
+
+	if err = writeCacheData(c.path, merged); err != nil {
+		return err
+	}
+	c.data = merged
+	c.dirty = map[string]bool{}
+	return nil
 }
 
 func NewCache(data map[string]ProjectInfo) *Cache {
 	return &Cache{
-		Data: data,
+		data:  data,
+		dirty: map[string]bool{},
+		path:  cachePath,
 	}
 }
 
-func NewCacheFromFile() *Cache {
-	data, err := os.ReadFile(cachePath)
-	if os.IsNotExist(err) {
-		return createCacheFile()
+// CachePath returns the cache file of the workspace. Projects outside of workspaces share the default one.
+func CachePath(workspace string) string {
+	if workspace == "" {
+		return cachePath
 	}
+	return filepath.Join(filepath.Dir(cachePath), "workspaces", workspace, "projects.json")
+}
 
+// NewCacheFromFile reads the cache of the workspace creating it if needed. A corrupt cache is backed up and replaced
+// by an empty one, a cache of the old format is migrated.
+func NewCacheFromFile(workspace string) (*Cache, error) {
+	return readCacheFile(CachePath(workspace))
+}
+
+// ReadCacheFile reads the cache of the workspace without touching the disk. A missing or corrupt cache is read as an
+// empty one, a cache of the old format is not migrated.
+func ReadCacheFile(workspace string) (*Cache, error) {
+	path := CachePath(workspace)
+	data, _, err := readCacheData(path)
+	var cacheErr *CacheError
+	if errors.Is(err, fs.ErrNotExist) {
+		data = map[string]ProjectInfo{}
+	} else if errors.As(err, &cacheErr) && cacheErr.Op == "decode" {
+		log.Printf("%s. It is read as an empty one", err)
+		data = map[string]ProjectInfo{}
+	} else if err != nil {
+		return nil, err
+	}
+	return &Cache{data: data, dirty: map[string]bool{}, path: path}, nil
+}
+
+func readCacheFile(path string) (*Cache, error) {
+	data, legacy, err := readCacheData(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return createCacheFile(path)
+	}
+	var cacheErr *CacheError
+	if errors.As(err, &cacheErr) && cacheErr.Op == "decode" {
+		return recoverCacheFile(path, cacheErr.Err)
+	}
 	if err != nil {
-		log.Fatalf("failed to read the cache file at %s: %s", cachePath, err)
+		return nil, err
 	}
 
-	var data_ map[string]ProjectInfo
-	err = json.Unmarshal(data, &data_)
+	cache := &Cache{data: data, dirty: map[string]bool{}, path: path}
+	if legacy {
+		log.Printf("migrating the cache file at %s to version %d", path, cacheVersion)
+		for project := range data {
+			cache.dirty[project] = true
+		}
+		if err = cache.write(); err != nil {
+			return nil, err
+		}
+	}
+	return cache, nil
+}
+
+// readCacheData reads the projects from the file. legacy is true if the file is a bare map of projects.
+func readCacheData(path string) (map[string]ProjectInfo, bool, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		log.Fatalf("failed to unmarshal the cache file at %s: %s", cachePath, err)
+		return nil, false, &CacheError{Path: path, Op: "read", Err: err}
 	}
 
-	return NewCache(data_)
+	projects, legacy, err := decodeCacheData(data)
+	var unsupported *unsupportedVersionError
+	if errors.As(err, &unsupported) {
+		return nil, false, &CacheError{Path: path, Op: "read", Err: err}
+	}
+	if err != nil {
+		return nil, false, &CacheError{Path: path, Op: "decode", Err: err}
+	}
+	return projects, legacy, nil
 }
 
-func getCacheDir() string {
-	systemDir, err := os.UserCacheDir()
+type unsupportedVersionError struct {
+	version int
+}
+
+func (e *unsupportedVersionError) Error() string {
+	return fmt.Sprintf("unsupported version %d, a newer gw is needed", e.version)
+}
+
+func decodeCacheData(data []byte) (projects map[string]ProjectInfo, legacy bool, err error) {
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, false, err
+	}
+	// A legacy project may be called "version" too, but it is an object then
+	var version int
+	if json.Unmarshal(fields["version"], &version) != nil {
+		if err = json.Unmarshal(data, &projects); err != nil {
+			return nil, false, err
+		}
+		legacy = true
+	} else {
+		if version > cacheVersion {
+			return nil, false, &unsupportedVersionError{version: version}
+		}
+		var file cacheFile
+		if err = json.Unmarshal(data, &file); err != nil {
+			return nil, false, err
+		}
+		projects = file.Projects
+	}
+
+	if projects == nil {
+		projects = map[string]ProjectInfo{}
+	}
+	return projects, legacy, nil
+}
+
+func encodeCacheData(projects map[string]ProjectInfo) ([]byte, error) {
+	data, err := json.MarshalIndent(cacheFile{Version: cacheVersion, Projects: projects}, "", "  ")
 	if err != nil {
-		log.Fatalf("failed to get the user cache directory: %s", err)
+		return nil, err
 	}
+	return append(data, '\n'), nil
+}
 
-	return path.Join(systemDir, "git_workon")
+// ExportCache writes the cached projects in the cache file format.
+func ExportCache(cache ICache, w io.Writer) error {
+	data, err := encodeCacheData(cache.List())
+	if err != nil {
+		return fmt.Errorf("failed to encode the cache: %s", err)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// ImportCache adds the projects exported by ExportCache, or read from a cache file of any version, to the cache. The
+// cached projects are kept unless overwrite is set. It returns the names of the imported projects.
+func ImportCache(cache ICache, r io.Reader, overwrite bool) ([]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the projects to import: %s", err)
+	}
+	projects, _, err := decodeCacheData(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the projects to import: %s", err)
+	}
+
+	existing := cache.List()
+	imported := []string{}
+	for _, project := range slices.Sorted(maps.Keys(projects)) {
+		if _, ok := existing[project]; ok && !overwrite {
+			log.Printf("\"%s\" is already cached. Skipped", project)
+			continue
+		}
+		cache.Set(project, projects[project])
+		imported = append(imported, project)
+	}
+	if len(imported) > 0 {
+		if err := cache.Write(); err != nil {
+			return nil, err
+		}
+	}
+	return imported, nil
 }
-func createCacheFile() *Cache {
-	data := map[string]ProjectInfo{}
 
-	dir := filepath.Dir(cachePath)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		log.Printf("failed to create cache dirs %s: %s", dir, err)
-		return nil
+// ForgetProjects removes the projects from the cache. Nothing is removed if any of them is not cached.
+func ForgetProjects(cache ICache, projects []string) error {
+	cached := cache.List()
+	for _, project := range projects {
+		if _, ok := cached[project]; !ok {
+			return fmt.Errorf("\"%s\" is not cached", project)
+		}
+	}
+	for _, project := range projects {
+		cache.Delete(project)
 	}
+	return cache.Write()
+}
 
-	// Create the cache file only if it does not exist
-	f, err := os.OpenFile(cachePath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+// SetProjectSource replaces the source of the project. The URL it was cloned from is dropped as it may not match.
+func SetProjectSource(cache ICache, project, source string) error {
+	if err := checkSource(source); err != nil {
+		return err
+	}
+	info := cache.Get(project)
+	info.Source = source
+	info.URL = ""
+	cache.Set(project, info)
+	return cache.Write()
+}
+
+// writeCacheData replaces the file atomically, so readers never see a partially written one.
+func writeCacheData(path string, projects map[string]ProjectInfo) error {
+	data, err := encodeCacheData(projects)
+	if err != nil {
+		return &CacheError{Path: path, Op: "encode", Err: err}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return &CacheError{Path: path, Op: "write", Err: err}
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
 	if err != nil {
-		// If the file already exists, there's nothing to do
-		if pe, ok := err.(*os.PathError); ok && pe.Err == syscall.EEXIST {
-			return nil
+		return &CacheError{Path: path, Op: "write", Err: err}
+	}
+	return nil
+}
+
+// lockCacheFile creates the lock file next to the cache and returns the function removing it. A lock older than
+// cacheLockStaleAge was left by a crashed process and is taken over.
+func lockCacheFile(path string) (func(), error) {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(cacheLockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
 		}
-		log.Printf("failed to create cache file %s: %s", cachePath, err)
-		return nil
+
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > cacheLockStaleAge {
+			log.Printf("taking over the stale lock %s", lockPath)
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for %s. Remove it if no other gw is running", lockPath)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func getCacheDir() string {
+	systemDir, err := os.UserCacheDir()
+	if err != nil {
+		log.Printf("failed to get the user cache directory: %s. Using the temporary one", err)
+		systemDir = os.TempDir()
 	}
-	defer f.Close()
 
-	// Write an empty JSON object to initialize the cache
-	encoder := json.NewEncoder(f)
-	if err := encoder.Encode(data); err != nil {
-		log.Printf("failed to write initial cache to %s: %s", cachePath, err)
-		return nil
+	return path.Join(systemDir, "git_workon")
+}
+
+func createCacheFile(path string) (*Cache, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, &CacheError{Path: path, Op: "create", Err: err}
 	}
 
-	return NewCache(data)
+	// Merged with the file if it was created concurrently
+	cache := &Cache{data: map[string]ProjectInfo{}, dirty: map[string]bool{}, path: path}
+	if err := cache.write(); err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
+func recoverCacheFile(path string, cause error) (*Cache, error) {
+	unlock, err := lockCacheFile(path)
+	if err != nil {
+		return nil, &CacheError{Path: path, Op: "lock", Err: err}
+	}
+	backupPath := fmt.Sprintf("%s.%s.bak", path, time.Now().Format("20060102150405"))
+	err = os.Rename(path, backupPath)
+	unlock()
+	if err != nil {
+		return nil, &CacheError{Path: path, Op: "back up", Err: err}
+	}
+	log.Printf("the cache file at %s is corrupt (%s). Backed up to %s and rebuilt", path, cause, backupPath)
+	return createCacheFile(path)
 }
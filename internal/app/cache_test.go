@@ -0,0 +1,329 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func setupCachePath(t *testing.T) string {
+	oldPath := cachePath
+	cachePath = filepath.Join(t.TempDir(), "git_workon", "projects.json")
+	t.Cleanup(func() { cachePath = oldPath })
+	return cachePath
+}
+
+func TestNewCacheFromFile(t *testing.T) {
+	t.Run("missing; created", func(t *testing.T) {
+		path := setupCachePath(t)
+
+		cache, err := NewCacheFromFile("")
+		require.NoError(t, err)
+		require.Empty(t, cache.List())
+		require.FileExists(t, path)
+	})
+	t.Run("existing; loaded", func(t *testing.T) {
+		path := setupCachePath(t)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(`{"version": 1, "projects": {"proj": {"source": "src"}}}`), 0o644))
+
+		cache, err := NewCacheFromFile("")
+		require.NoError(t, err)
+		require.Equal(t, ProjectInfo{Source: "src"}, cache.Get("proj"))
+	})
+	t.Run("legacy; migrated", func(t *testing.T) {
+		path := setupCachePath(t)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(`{"proj": {"source": "src"}, "version": {"source": "v"}}`), 0o644))
+
+		cache, err := NewCacheFromFile("")
+		require.NoError(t, err)
+		require.Equal(t, ProjectInfo{Source: "src"}, cache.Get("proj"))
+		require.Equal(t, ProjectInfo{Source: "v"}, cache.Get("version"))
+
+		projects, legacy, err := readCacheData(path)
+		require.NoError(t, err)
+		require.False(t, legacy)
+		require.Equal(t, cache.List(), projects)
+	})
+	t.Run("newer version; error", func(t *testing.T) {
+		path := setupCachePath(t)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(`{"version": 99, "projects": {}}`), 0o644))
+
+		_, err := NewCacheFromFile("")
+		require.ErrorContains(t, err, "unsupported version 99")
+	})
+	t.Run("workspace; separate file", func(t *testing.T) {
+		path := setupCachePath(t)
+
+		cache, err := NewCacheFromFile("work")
+		require.NoError(t, err)
+		cache.Set("proj", ProjectInfo{Source: "src"})
+		require.NoError(t, cache.Write())
+
+		require.NoFileExists(t, path)
+		require.FileExists(t, filepath.Join(filepath.Dir(path), "workspaces", "work", "projects.json"))
+		cache, err = NewCacheFromFile("work")
+		require.NoError(t, err)
+		require.Equal(t, ProjectInfo{Source: "src"}, cache.Get("proj"))
+	})
+	t.Run("corrupt; backed up and rebuilt", func(t *testing.T) {
+		path := setupCachePath(t)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(`{"proj": `), 0o644))
+
+		cache, err := NewCacheFromFile("")
+		require.NoError(t, err)
+		require.Empty(t, cache.List())
+
+		backups, err := filepath.Glob(path + ".*.bak")
+		require.NoError(t, err)
+		require.Len(t, backups, 1)
+		data, err := os.ReadFile(backups[0])
+		require.NoError(t, err)
+		require.Equal(t, `{"proj": `, string(data))
+	})
+	t.Run("unreadable; error", func(t *testing.T) {
+		path := setupCachePath(t)
+		require.NoError(t, os.MkdirAll(path, 0o755))
+
+		_, err := NewCacheFromFile("")
+		var cacheErr *CacheError
+		require.ErrorAs(t, err, &cacheErr)
+		require.Equal(t, path, cacheErr.Path)
+	})
+}
+
+func TestReadCacheFile(t *testing.T) {
+	t.Run("missing; not created", func(t *testing.T) {
+		path := setupCachePath(t)
+
+		cache, err := ReadCacheFile("")
+		require.NoError(t, err)
+		require.Empty(t, cache.List())
+		require.NoDirExists(t, filepath.Dir(path))
+	})
+	t.Run("legacy; not migrated", func(t *testing.T) {
+		path := setupCachePath(t)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		legacy := `{"proj": {"source": "src"}}`
+		require.NoError(t, os.WriteFile(path, []byte(legacy), 0o644))
+
+		cache, err := ReadCacheFile("")
+		require.NoError(t, err)
+		require.Equal(t, ProjectInfo{Source: "src"}, cache.Get("proj"))
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, legacy, string(data))
+	})
+	t.Run("corrupt; not backed up", func(t *testing.T) {
+		path := setupCachePath(t)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(`{"proj": `), 0o644))
+
+		cache, err := ReadCacheFile("")
+		require.NoError(t, err)
+		require.Empty(t, cache.List())
+		backups, err := filepath.Glob(path + ".*.bak")
+		require.NoError(t, err)
+		require.Empty(t, backups)
+		require.FileExists(t, path)
+	})
+	t.Run("newer version; error", func(t *testing.T) {
+		path := setupCachePath(t)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(`{"version": 99, "projects": {}}`), 0o644))
+
+		_, err := ReadCacheFile("")
+		require.ErrorContains(t, err, "unsupported version 99")
+	})
+}
+
+func TestCacheWrite(t *testing.T) {
+	t.Run("concurrent caches; merged", func(t *testing.T) {
+		path := setupCachePath(t)
+		first, err := NewCacheFromFile("")
+		require.NoError(t, err)
+		second, err := NewCacheFromFile("")
+		require.NoError(t, err)
+
+		first.Set("p1", ProjectInfo{Source: "s1"})
+		second.Set("p2", ProjectInfo{Source: "s2"})
+		require.NoError(t, first.Write())
+		require.NoError(t, second.Write())
+
+		expected := map[string]ProjectInfo{"p1": {Source: "s1"}, "p2": {Source: "s2"}}
+		require.Equal(t, expected, second.List())
+		projects, _, err := readCacheData(path)
+		require.NoError(t, err)
+		require.Equal(t, expected, projects)
+	})
+	t.Run("parallel writers; nothing lost", func(t *testing.T) {
+		path := setupCachePath(t)
+		var wg sync.WaitGroup
+		for i := range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				cache, err := NewCacheFromFile("")
+				require.NoError(t, err)
+				cache.Set(fmt.Sprintf("p%d", i), ProjectInfo{Source: "s"})
+				require.NoError(t, cache.Write())
+			}()
+		}
+		wg.Wait()
+
+		projects, _, err := readCacheData(path)
+		require.NoError(t, err)
+		require.Len(t, projects, 10)
+		leftovers, err := filepath.Glob(filepath.Join(filepath.Dir(path), "*.tmp"))
+		require.NoError(t, err)
+		require.Empty(t, leftovers)
+		require.NoFileExists(t, path+".lock")
+	})
+	t.Run("stale lock; taken over", func(t *testing.T) {
+		path := setupCachePath(t)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path+".lock", []byte("1\n"), 0o644))
+		old := time.Now().Add(-2 * cacheLockStaleAge)
+		require.NoError(t, os.Chtimes(path+".lock", old, old))
+
+		_, err := NewCacheFromFile("")
+		require.NoError(t, err)
+		require.NoFileExists(t, path+".lock")
+	})
+	t.Run("held lock; timed out", func(t *testing.T) {
+		path := setupCachePath(t)
+		oldTimeout := cacheLockTimeout
+		cacheLockTimeout = 100 * time.Millisecond
+		t.Cleanup(func() { cacheLockTimeout = oldTimeout })
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path+".lock", []byte("1\n"), 0o644))
+
+		_, err := NewCacheFromFile("")
+		var cacheErr *CacheError
+		require.ErrorAs(t, err, &cacheErr)
+		require.Equal(t, "lock", cacheErr.Op)
+	})
+	t.Run("held lock; write failed and kept pending", func(t *testing.T) {
+		path := setupCachePath(t)
+		oldTimeout := cacheLockTimeout
+		cacheLockTimeout = 100 * time.Millisecond
+		t.Cleanup(func() { cacheLockTimeout = oldTimeout })
+		cache, err := NewCacheFromFile("")
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path+".lock", []byte("1\n"), 0o644))
+
+		cache.Set("proj", ProjectInfo{Source: "s"})
+		err = cache.Write()
+		var cacheErr *CacheError
+		require.ErrorAs(t, err, &cacheErr)
+		require.Equal(t, "lock", cacheErr.Op)
+
+		require.NoError(t, os.Remove(path+".lock"))
+		require.NoError(t, cache.Write())
+		projects, _, err := readCacheData(path)
+		require.NoError(t, err)
+		require.Equal(t, map[string]ProjectInfo{"proj": {Source: "s"}}, projects)
+	})
+}
+
+func TestCacheDelete(t *testing.T) {
+	path := setupCachePath(t)
+	first, err := NewCacheFromFile("")
+	require.NoError(t, err)
+	first.Set("p1", ProjectInfo{Source: "s1"})
+	first.Set("p2", ProjectInfo{Source: "s2"})
+	require.NoError(t, first.Write())
+
+	second, err := NewCacheFromFile("")
+	require.NoError(t, err)
+	second.Delete("p1")
+	first.Set("p3", ProjectInfo{Source: "s3"})
+	require.NoError(t, second.Write())
+	require.NoError(t, first.Write())
+
+	projects, _, err := readCacheData(path)
+	require.NoError(t, err)
+	require.Equal(t, map[string]ProjectInfo{"p2": {Source: "s2"}, "p3": {Source: "s3"}}, projects)
+}
+
+func TestExportImportCache(t *testing.T) {
+	source := NewCache(map[string]ProjectInfo{"p1": {Source: "s1", OpenCount: 2}, "p2": {Source: "s2"}})
+	var exported bytes.Buffer
+	require.NoError(t, ExportCache(source, &exported))
+
+	t.Run("existing kept", func(t *testing.T) {
+		cache := NewEmptyFakeCache()
+		cache.Set("p2", ProjectInfo{Source: "mine"})
+
+		imported, err := ImportCache(cache, bytes.NewReader(exported.Bytes()), false)
+		require.NoError(t, err)
+		require.Equal(t, []string{"p1"}, imported)
+		require.Equal(t, map[string]ProjectInfo{"p1": {Source: "s1", OpenCount: 2}, "p2": {Source: "mine"}}, cache.data)
+		require.Equal(t, 1, cache.Writes)
+	})
+	t.Run("existing overwritten", func(t *testing.T) {
+		cache := NewEmptyFakeCache()
+		cache.Set("p2", ProjectInfo{Source: "mine"})
+
+		imported, err := ImportCache(cache, bytes.NewReader(exported.Bytes()), true)
+		require.NoError(t, err)
+		require.Equal(t, []string{"p1", "p2"}, imported)
+		require.Equal(t, source.List(), cache.data)
+	})
+	t.Run("legacy format", func(t *testing.T) {
+		cache := NewEmptyFakeCache()
+
+		imported, err := ImportCache(cache, strings.NewReader(`{"p1": {"source": "s1"}}`), false)
+		require.NoError(t, err)
+		require.Equal(t, []string{"p1"}, imported)
+	})
+	t.Run("malformed", func(t *testing.T) {
+		_, err := ImportCache(NewEmptyFakeCache(), strings.NewReader(`[]`), false)
+		require.ErrorContains(t, err, "failed to decode")
+	})
+	t.Run("write failed", func(t *testing.T) {
+		cache := NewEmptyFakeCache()
+		cache.WriteErr = errors.New("lock timeout")
+
+		imported, err := ImportCache(cache, bytes.NewReader(exported.Bytes()), false)
+		require.ErrorContains(t, err, "lock timeout")
+		require.Empty(t, imported)
+	})
+}
+
+func TestSetProjectSource(t *testing.T) {
+	cache := NewFakeCache(map[string]ProjectInfo{"p1": {Source: "old", URL: "old/p1", OpenCount: 1}})
+
+	require.NoError(t, SetProjectSource(cache, "p1", "git@github.com:me"))
+	require.Equal(t, ProjectInfo{Source: "git@github.com:me", OpenCount: 1}, cache.data["p1"])
+	require.ErrorContains(t, SetProjectSource(cache, "p1", "relative/path"), "malformed source")
+	require.Equal(t, 1, cache.Writes)
+
+	cache.WriteErr = errors.New("lock timeout")
+	require.ErrorContains(t, SetProjectSource(cache, "p1", "git@github.com:other"), "lock timeout")
+}
+
+func TestForgetProjects(t *testing.T) {
+	cache := NewFakeCache(map[string]ProjectInfo{"p1": {Source: "s"}, "p2": {Source: "s"}})
+
+	require.ErrorContains(t, ForgetProjects(cache, []string{"p1", "missing"}), "\"missing\" is not cached")
+	require.Len(t, cache.data, 2)
+	require.Equal(t, 0, cache.Writes)
+
+	require.NoError(t, ForgetProjects(cache, []string{"p1"}))
+	require.Equal(t, map[string]ProjectInfo{"p2": {Source: "s"}}, cache.data)
+
+	cache.WriteErr = errors.New("lock timeout")
+	require.ErrorContains(t, ForgetProjects(cache, []string{"p2"}), "lock timeout")
+}
@@ -17,6 +17,7 @@ type CMDResult struct {
 type CMD interface {
 	Run(name string, args []string) (CMDResult, error)
 	RunCwd(dir string, name string, args []string) (CMDResult, error)
+	RunCwdEnv(dir string, env []string, name string, args []string) (CMDResult, error)
 	ShellRun(name string, args []string) (CMDResult, error)
 }
 
@@ -35,6 +36,14 @@ func (ose OSExec) RunCwd(dir string, name string, args []string) (CMDResult, err
 	return ose.run(cmd)
 }
 
+func (ose OSExec) RunCwdEnv(dir string, env []string, name string, args []string) (CMDResult, error) {
+	log.Printf("executing \"%s\" with args %s in \"%s\" with env %s", name, args, dir, env)
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	return ose.run(cmd)
+}
+
 func (ose OSExec) ShellRun(name string, args []string) (CMDResult, error) {
 	log.Printf("executing \"%s\" with args %s", name, args)
 	cmd := exec.Command(name, args...)
@@ -42,3 +42,18 @@ func TestOSExecRunCwd(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestOSExecRunCwdEnv(t *testing.T) {
+	t.Run("env passed", func(t *testing.T) {
+		exec := OSExec{}
+		dir := t.TempDir()
+		result, err := exec.RunCwdEnv(dir, []string{"GW_TEST=value"}, "sh", []string{"-c", "echo $GW_TEST; pwd"})
+		require.NoError(t, err)
+		require.Equal(t, "value\n"+dir+"\n", result.Stdout)
+	})
+	t.Run("non-zero exit; error", func(t *testing.T) {
+		exec := OSExec{}
+		_, err := exec.RunCwdEnv(t.TempDir(), []string{}, "sh", []string{"-c", "exit 1"})
+		require.Error(t, err)
+	})
+}
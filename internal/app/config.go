@@ -2,21 +2,99 @@ package app
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/BurntSushi/toml"
 	"github.com/kirsle/configdir"
+	"gopkg.in/yaml.v3"
 )
 
 var ConfigDir = configdir.LocalConfig("git_workon")
 var ConfigPath = filepath.Join(ConfigDir, "config.json")
+var SystemConfigDirs = configdir.SystemConfig("git_workon")
+
+const defaultTrashDays = 14
 
 type Config struct {
-	Dir     string   `json:"dir"`
-	Editor  string   `json:"editor"`
-	Sources []string `json:"sources"`
+	Dir              string                     `json:"dir"`
+	Editor           string                     `json:"editor"`
+	Sources          []string                   `json:"sources"`
+	TrashDays        int                        `json:"trash_days,omitempty"`
+	Clone            CloneOpts                  `json:"clone"`
+	SourceSettings   map[string]SourceSettings  `json:"source_settings,omitempty"`
+	Aliases          map[string]Alias           `json:"aliases,omitempty"`
+	Hooks            Hooks                      `json:"hooks"`
+	ProjectSettings  map[string]ProjectSettings `json:"project_settings,omitempty"`
+	Done             DonePolicy                 `json:"done"`
+	Workspaces       map[string]Workspace       `json:"workspaces,omitempty"`
+	DefaultWorkspace string                     `json:"default_workspace,omitempty"`
+
+	// Workspace is the name of the active workspace, empty if none is used.
+	Workspace string `json:"-"`
+}
+
+// Workspace overrides the working directory, the sources and the editor. Unset fields are taken from the top level.
+type Workspace struct {
+	Dir     string   `json:"dir,omitempty"`
+	Editor  string   `json:"editor,omitempty"`
+	Sources []string `json:"sources,omitempty"`
+}
+
+type ProjectSettings struct {
+	Hooks Hooks      `json:"hooks"`
+	Done  DonePolicy `json:"done"`
+}
+
+type Alias struct {
+	URL    string `json:"url,omitempty"`
+	Source string `json:"source,omitempty"`
+	Remote string `json:"remote,omitempty"`
+	Dir    string `json:"dir,omitempty"`
+}
+
+func (a Alias) dir(name string) string {
+	if a.Dir != "" {
+		return a.Dir
+	}
+	return name
+}
+
+func (a Alias) remote(name string) string {
+	if a.Remote != "" {
+		return a.Remote
+	}
+	return name
+}
+
+type SourceSettings struct {
+	Clone CloneOpts `json:"clone"`
+	Hooks Hooks     `json:"hooks"`
+}
+
+func (c Config) CloneOpts(source string) CloneOpts {
+	return c.Clone.Merge(c.SourceSettings[source].Clone)
+}
+
+func (c Config) HooksFor(project, source string) Hooks {
+	return c.Hooks.Merge(c.SourceSettings[source].Hooks).Merge(c.ProjectSettings[project].Hooks)
+}
+
+func (c Config) DonePolicyFor(project string) DonePolicy {
+	return c.Done.Merge(c.ProjectSettings[project].Done)
+}
+
+func (c Config) TrashRetention() time.Duration {
+	days := c.TrashDays
+	if days <= 0 {
+		days = defaultTrashDays
+	}
+	return time.Duration(days) * 24 * time.Hour
 }
 
 func (c Config) String() string {
@@ -30,57 +108,204 @@ func (c Config) String() string {
 
 func NewDefaultConfig() Config {
 	return Config{
-		Dir:     "~/.workon",
-		Editor:  "vi",
-		Sources: []string{},
+		Dir:       "~/.workon",
+		Editor:    "vi",
+		Sources:   []string{},
+		TrashDays: defaultTrashDays,
 	}
 }
 
-func LoadConfig() Config {
-	err := configdir.MakePath(ConfigDir)
-	if err != nil {
-		log.Fatalf("failed to ensure the configuration directory exists: %s", err)
-	}
+type ConfigOpts struct {
+	Path      string
+	Directory string
+	Workspace string
+}
 
-	var config Config
+var configExtensions = []string{".json", ".yaml", ".yml", ".toml"}
 
-	if _, err = os.Stat(ConfigPath); os.IsNotExist(err) {
-		config := NewDefaultConfig()
-		fh, err := os.Create(ConfigPath)
-		if err != nil {
-			log.Fatalf("failed to create the configuration file at %s: %s", ConfigPath, err)
-		}
-		defer fh.Close()
+// LoadConfig merges the system, the user and the working directory configurations, the later ones take precedence.
+// GW_CONFIG or the explicit path replaces the user configuration. The workspace (explicit, GW_WORKSPACE or the default
+// one) is applied next. GW_DIR, GW_EDITOR and GW_SOURCES override the result.
+//
+// A missing configuration is reported as *ConfigNotFoundError, a broken file as *ConfigError.
+func LoadConfig(opts ConfigOpts) (Config, error) {
+	paths := systemConfigPaths()
+	userPath := UserConfigPath(opts.Path)
+	if userPath != ConfigPath || fileExists(userPath) {
+		paths = append(paths, userPath)
+	} else if len(paths) == 0 {
+		return Config{}, &ConfigNotFoundError{Path: ConfigPath}
+	}
 
-		encoder := json.NewEncoder(fh)
-		err = encoder.Encode(&config)
+	merged := map[string]any{}
+	for _, path := range paths {
+		layer, err := readConfigLayer(path)
 		if err != nil {
-			log.Fatalf("failed to encode config to %s: %s", ConfigPath, err)
+			return Config{}, err
 		}
+		mergeConfigLayers(merged, layer)
+	}
+	workspace, err := applyWorkspace(merged, opts.Workspace)
+	if err != nil {
+		return Config{}, err
+	}
+	config, err := decodeConfig(merged)
+	if err != nil {
+		return Config{}, err
+	}
 
-		log.Fatalf("missing configuration at %s", ConfigPath)
-	} else {
-		fh, err := os.Open(ConfigPath)
+	dir := opts.Directory
+	if dir == "" {
+		dir = os.Getenv("GW_DIR")
+	}
+	if dir == "" {
+		dir = config.Dir
+	}
+	dir, err = expandHome(dir)
+	if err != nil {
+		return Config{}, err
+	}
+	if path := findConfigFile(dir, ".gw"); path != "" {
+		layer, err := readConfigLayer(path)
 		if err != nil {
-			log.Fatalf("failed to open configuration file at %s: %s", ConfigPath, err)
+			return Config{}, err
+		}
+		delete(layer, "dir")
+		mergeConfigLayers(merged, layer)
+		if config, err = decodeConfig(merged); err != nil {
+			return Config{}, err
 		}
-		defer fh.Close()
+	}
+	config.Workspace = workspace
 
-		decoder := json.NewDecoder(fh)
-		err = decoder.Decode(&config)
+	if value := os.Getenv("GW_DIR"); value != "" {
+		config.Dir = value
+	}
+	if value := os.Getenv("GW_EDITOR"); value != "" {
+		config.Editor = value
+	}
+	if value := os.Getenv("GW_SOURCES"); value != "" {
+		config.Sources = splitList(value)
+	}
 
-		if err != nil {
-			log.Fatalf("failed to decode configuration from %s: %s", ConfigPath, err)
+	config.Dir, err = expandHome(config.Dir)
+	if err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// applyWorkspace merges the workspace (explicit, GW_WORKSPACE or the default one) over the top level and returns its
+// name.
+func applyWorkspace(merged map[string]any, name string) (string, error) {
+	if name == "" {
+		name = os.Getenv("GW_WORKSPACE")
+	}
+	if name == "" {
+		name, _ = merged["default_workspace"].(string)
+	}
+	if name == "" {
+		return "", nil
+	}
+
+	workspaces, _ := merged["workspaces"].(map[string]any)
+	workspace, ok := workspaces[name].(map[string]any)
+	if !ok {
+		return "", fmt.Errorf("unknown workspace \"%s\". Run \"gw workspace add %s\" to create it", name, name)
+	}
+	mergeConfigLayers(merged, workspace)
+	return name, nil
+}
+
+// systemConfigPaths returns the system configuration files, the most important last.
+func systemConfigPaths() []string {
+	paths := []string{}
+	for i := len(SystemConfigDirs) - 1; i >= 0; i-- {
+		if path := findConfigFile(SystemConfigDirs[i], "config"); path != "" {
+			paths = append(paths, path)
 		}
 	}
+	return paths
+}
 
-	if strings.HasPrefix(config.Dir, "~") {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			log.Fatalf("failed to get home directory: %s", err)
+func findConfigFile(dir, name string) string {
+	for _, ext := range configExtensions {
+		path := filepath.Join(dir, name+ext)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+func readConfigLayer(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &ConfigError{Path: path, Op: "read", Err: err}
+	}
+
+	layer := map[string]any{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &layer)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &layer)
+	case ".toml":
+		err = toml.Unmarshal(data, &layer)
+	default:
+		return nil, &ConfigError{Path: path, Op: "read", Err: errors.New("unsupported format")}
+	}
+	if err != nil {
+		return nil, &ConfigError{Path: path, Op: "decode", Err: err}
+	}
+	if layer == nil {
+		layer = map[string]any{}
+	}
+	return layer, nil
+}
+
+// mergeConfigLayers merges objects recursively. Other values, including lists, are replaced.
+func mergeConfigLayers(dst, src map[string]any) {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]any)
+		dstMap, dstIsMap := dst[key].(map[string]any)
+		if srcIsMap && dstIsMap {
+			mergeConfigLayers(dstMap, srcMap)
+		} else {
+			dst[key] = value
 		}
-		config.Dir = strings.Replace(config.Dir, "~", homeDir, 1)
 	}
+}
 
-	return config
+func decodeConfig(layers map[string]any) (Config, error) {
+	data, err := json.Marshal(layers)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to merge configuration: %s", err)
+	}
+	config := NewDefaultConfig()
+	if err = json.Unmarshal(data, &config); err != nil {
+		return Config{}, fmt.Errorf("failed to decode configuration: %s", err)
+	}
+	return config, nil
+}
+
+func expandHome(path string) (string, error) {
+	if !strings.HasPrefix(path, "~") {
+		return path, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %s", err)
+	}
+	return strings.Replace(path, "~", homeDir, 1), nil
+}
+
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
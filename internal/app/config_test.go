@@ -0,0 +1,169 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func setupConfigDirs(t *testing.T) (string, string) {
+	root := t.TempDir()
+	systemDir := filepath.Join(root, "system")
+	userDir := filepath.Join(root, "user")
+	require.NoError(t, os.MkdirAll(systemDir, 0755))
+	require.NoError(t, os.MkdirAll(userDir, 0755))
+
+	oldSystemDirs, oldDir, oldPath := SystemConfigDirs, ConfigDir, ConfigPath
+	SystemConfigDirs = []string{systemDir}
+	ConfigDir = userDir
+	ConfigPath = filepath.Join(userDir, "config.json")
+	t.Cleanup(func() {
+		SystemConfigDirs, ConfigDir, ConfigPath = oldSystemDirs, oldDir, oldPath
+	})
+	for _, name := range []string{"GW_CONFIG", "GW_DIR", "GW_EDITOR", "GW_SOURCES", "GW_WORKSPACE"} {
+		t.Setenv(name, "")
+	}
+	return systemDir, userDir
+}
+
+func writeFile(t *testing.T, path, content string) {
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestLoadConfigLayers(t *testing.T) {
+	systemDir, userDir := setupConfigDirs(t)
+	workDir := t.TempDir()
+
+	writeFile(t, filepath.Join(systemDir, "config.yaml"), `
+sources:
+  - git@gitlab.corp:team
+editor: code
+clone:
+  filter: blob:none
+hooks:
+  post_clone: ["make setup"]
+`)
+	writeFile(t, filepath.Join(userDir, "config.toml"), `
+dir = "`+workDir+`"
+editor = "vim"
+
+[clone]
+depth = 1
+`)
+	writeFile(t, filepath.Join(workDir, ".gw.json"), `{"dir": "/ignored", "trash_days": 3, "clone": {"no_tags": true}}`)
+
+	config, err := LoadConfig(ConfigOpts{})
+	require.NoError(t, err)
+	require.Equal(t, workDir, config.Dir)
+	require.Equal(t, "vim", config.Editor)
+	require.Equal(t, []string{"git@gitlab.corp:team"}, config.Sources)
+	require.Equal(t, 3, config.TrashDays)
+	require.Equal(t, CloneOpts{Depth: 1, Filter: "blob:none", NoTags: true}, config.Clone)
+	require.Equal(t, []string{"make setup"}, config.Hooks.PostClone)
+
+	t.Run("working directory override", func(t *testing.T) {
+		config, err := LoadConfig(ConfigOpts{Directory: t.TempDir()})
+		require.NoError(t, err)
+		require.Equal(t, defaultTrashDays, config.TrashDays)
+	})
+	t.Run("explicit config replaces the user one", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "team.yml")
+		writeFile(t, path, "dir: /team\n")
+
+		config, err := LoadConfig(ConfigOpts{Path: path})
+		require.NoError(t, err)
+		require.Equal(t, "/team", config.Dir)
+		require.Equal(t, "code", config.Editor)
+	})
+	t.Run("env overrides", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "env.json")
+		writeFile(t, path, `{"editor": "nano"}`)
+		t.Setenv("GW_CONFIG", path)
+		t.Setenv("GW_DIR", "/from/env")
+		t.Setenv("GW_EDITOR", "emacs")
+		t.Setenv("GW_SOURCES", "https://github.com/a, git@github.com:b")
+
+		config, err := LoadConfig(ConfigOpts{})
+		require.NoError(t, err)
+		require.Equal(t, "/from/env", config.Dir)
+		require.Equal(t, "emacs", config.Editor)
+		require.Equal(t, []string{"https://github.com/a", "git@github.com:b"}, config.Sources)
+	})
+}
+
+func TestLoadConfigWorkspaces(t *testing.T) {
+	_, userDir := setupConfigDirs(t)
+	workDir := t.TempDir()
+	writeFile(t, filepath.Join(userDir, "config.json"), `{
+		"dir": "/personal",
+		"editor": "vim",
+		"sources": ["git@github.com:me"],
+		"default_workspace": "work",
+		"workspaces": {
+			"work": {"dir": "`+workDir+`", "sources": ["git@corp:team"]},
+			"oss": {"dir": "/oss", "editor": "code"}
+		}
+	}`)
+	writeFile(t, filepath.Join(workDir, ".gw.json"), `{"editor": "nano"}`)
+
+	config, err := LoadConfig(ConfigOpts{})
+	require.NoError(t, err)
+	require.Equal(t, "work", config.Workspace)
+	require.Equal(t, workDir, config.Dir)
+	require.Equal(t, "nano", config.Editor)
+	require.Equal(t, []string{"git@corp:team"}, config.Sources)
+
+	config, err = LoadConfig(ConfigOpts{Workspace: "oss"})
+	require.NoError(t, err)
+	require.Equal(t, "oss", config.Workspace)
+	require.Equal(t, "/oss", config.Dir)
+	require.Equal(t, "code", config.Editor)
+	require.Equal(t, []string{"git@github.com:me"}, config.Sources)
+
+	t.Setenv("GW_WORKSPACE", "oss")
+	config, err = LoadConfig(ConfigOpts{})
+	require.NoError(t, err)
+	require.Equal(t, "oss", config.Workspace)
+
+	_, err = LoadConfig(ConfigOpts{Workspace: "missing"})
+	require.ErrorContains(t, err, "unknown workspace \"missing\"")
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	t.Run("missing configuration", func(t *testing.T) {
+		setupConfigDirs(t)
+
+		_, err := LoadConfig(ConfigOpts{})
+		var notFound *ConfigNotFoundError
+		require.ErrorAs(t, err, &notFound)
+		require.Equal(t, ConfigPath, notFound.Path)
+		require.ErrorContains(t, err, "gw config init")
+		require.NoFileExists(t, ConfigPath)
+	})
+	t.Run("missing explicit configuration", func(t *testing.T) {
+		setupConfigDirs(t)
+
+		_, err := LoadConfig(ConfigOpts{Path: "/does/not/exist.json"})
+		require.ErrorContains(t, err, "failed to read")
+	})
+	t.Run("unsupported format", func(t *testing.T) {
+		setupConfigDirs(t)
+		path := filepath.Join(t.TempDir(), "config.ini")
+		writeFile(t, path, "dir=/x")
+
+		_, err := LoadConfig(ConfigOpts{Path: path})
+		require.ErrorContains(t, err, "unsupported format")
+	})
+	t.Run("malformed", func(t *testing.T) {
+		_, userDir := setupConfigDirs(t)
+		writeFile(t, filepath.Join(userDir, "config.json"), "{")
+
+		_, err := LoadConfig(ConfigOpts{})
+		var configErr *ConfigError
+		require.ErrorAs(t, err, &configErr)
+		require.Equal(t, "decode", configErr.Op)
+		require.ErrorContains(t, err, "failed to decode")
+	})
+}
@@ -0,0 +1,433 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile is a single configuration layer edited in place. Keys are dotted JSON names, e.g. "clone.depth".
+// Map keys containing dots are double-quoted, e.g. `source_settings."git@github.com:me".clone.depth`.
+type ConfigFile struct {
+	Path string
+	data map[string]any
+}
+
+// UserConfigPath returns the file edited by the config commands: the explicit one, GW_CONFIG or the user one.
+func UserConfigPath(explicit string) string {
+	if explicit != "" {
+		return explicit
+	}
+	if path := os.Getenv("GW_CONFIG"); path != "" {
+		return path
+	}
+	if path := findConfigFile(ConfigDir, "config"); path != "" {
+		return path
+	}
+	return ConfigPath
+}
+
+func NewConfigFile(path string) *ConfigFile {
+	return &ConfigFile{Path: path, data: map[string]any{}}
+}
+
+func OpenConfigFile(path string) (*ConfigFile, error) {
+	file := NewConfigFile(path)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return file, nil
+	}
+	data, err := readConfigLayer(path)
+	if err != nil {
+		return nil, err
+	}
+	file.data = data
+	return file, nil
+}
+
+func (f *ConfigFile) Set(key string, values []string, appendValues bool) error {
+	segments, keyType, err := parseConfigKey(key)
+	if err != nil {
+		return err
+	}
+	value, err := parseConfigValue(key, keyType, values)
+	if err != nil {
+		return err
+	}
+
+	parent := f.data
+	for _, segment := range segments[:len(segments)-1] {
+		child, ok := parent[segment].(map[string]any)
+		if !ok {
+			child = map[string]any{}
+			parent[segment] = child
+		}
+		parent = child
+	}
+
+	last := segments[len(segments)-1]
+	if appendValues {
+		if keyType.Kind() != reflect.Slice {
+			return fmt.Errorf("\"%s\" is not a list", key)
+		}
+		existing, _ := parent[last].([]any)
+		value = append(slices.Clone(existing), value.([]any)...)
+	}
+	parent[last] = value
+	return nil
+}
+
+func (f *ConfigFile) Unset(key string) error {
+	segments, err := splitConfigKey(key)
+	if err != nil {
+		return err
+	}
+	parent := f.data
+	for _, segment := range segments[:len(segments)-1] {
+		child, ok := parent[segment].(map[string]any)
+		if !ok {
+			return fmt.Errorf("\"%s\" is not set in %s", key, f.Path)
+		}
+		parent = child
+	}
+
+	last := segments[len(segments)-1]
+	if _, ok := parent[last]; !ok {
+		return fmt.Errorf("\"%s\" is not set in %s", key, f.Path)
+	}
+	delete(parent, last)
+	return nil
+}
+
+func (f *ConfigFile) Save() error {
+	var data []byte
+	var err error
+	switch strings.ToLower(filepath.Ext(f.Path)) {
+	case ".json":
+		data, err = json.MarshalIndent(f.data, "", "  ")
+		data = append(data, '\n')
+	case ".yaml", ".yml":
+		data, err = yaml.Marshal(f.data)
+	case ".toml":
+		var buf bytes.Buffer
+		err = toml.NewEncoder(&buf).Encode(f.data)
+		data = buf.Bytes()
+	default:
+		return fmt.Errorf("unsupported configuration format of %s", f.Path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode configuration for %s: %s", f.Path, err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(f.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create the configuration directory for %s: %s", f.Path, err)
+	}
+	if err = os.WriteFile(f.Path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write configuration to %s: %s", f.Path, err)
+	}
+	return nil
+}
+
+// AddWorkspace adds the workspace to the file. The workspace becomes the default one if asked to.
+func (f *ConfigFile) AddWorkspace(name string, workspace Workspace, makeDefault bool) error {
+	if name == "" || strings.ContainsAny(name, "./\\") {
+		return fmt.Errorf("invalid workspace name \"%s\"", name)
+	}
+	if workspace.Dir == "" {
+		return fmt.Errorf("the directory of the workspace \"%s\" is required", name)
+	}
+	workspaces, _ := f.data["workspaces"].(map[string]any)
+	if _, ok := workspaces[name]; ok {
+		return fmt.Errorf("the workspace \"%s\" already exists in %s", name, f.Path)
+	}
+
+	key := "workspaces." + name
+	if err := f.Set(key+".dir", []string{workspace.Dir}, false); err != nil {
+		return err
+	}
+	if workspace.Editor != "" {
+		if err := f.Set(key+".editor", []string{workspace.Editor}, false); err != nil {
+			return err
+		}
+	}
+	if len(workspace.Sources) > 0 {
+		if err := f.Set(key+".sources", workspace.Sources, false); err != nil {
+			return err
+		}
+	}
+	if makeDefault {
+		return f.Set("default_workspace", []string{name}, false)
+	}
+	return nil
+}
+
+// RemoveWorkspace removes the workspace from the file. It stops being the default one too.
+func (f *ConfigFile) RemoveWorkspace(name string) error {
+	if err := f.Unset("workspaces." + name); err != nil {
+		return fmt.Errorf("the workspace \"%s\" is not defined in %s", name, f.Path)
+	}
+	if workspaces, _ := f.data["workspaces"].(map[string]any); len(workspaces) == 0 {
+		delete(f.data, "workspaces")
+	}
+	if f.data["default_workspace"] == name {
+		delete(f.data, "default_workspace")
+	}
+	return nil
+}
+
+// ConfigValue returns the value of the key in the effective configuration or nil if it is not set.
+func ConfigValue(config Config, key string) (any, error) {
+	segments, _, err := parseConfigKey(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %s", err)
+	}
+	var value any
+	if err = json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %s", err)
+	}
+
+	for _, segment := range segments {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, nil
+		}
+		value = object[segment]
+	}
+	return value, nil
+}
+
+// EditConfig opens the user configuration in the editor. A broken configuration may be edited too, the configured
+// editor is just not used then.
+func EditConfig(opts ConfigOpts, editor string) error {
+	path := UserConfigPath(opts.Path)
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("failed to edit %s: %s. Run \"gw config init\" to create it", path, err)
+	}
+	config, err := LoadConfig(opts)
+	if err != nil {
+		log.Println(err)
+	}
+
+	fs := NewOSFileSystem(NewOSExec())
+	for _, editor_ := range editorsFor(editor, config.Editor) {
+		if err := fs.Open(path, editor_); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to open \"%s\". Tried all configured editors", path)
+}
+
+// ValidateConfig checks every configuration layer and the merged result. It returns the problems found.
+func ValidateConfig(opts ConfigOpts) []string {
+	problems := []string{}
+	paths := systemConfigPaths()
+	if path := UserConfigPath(opts.Path); fileExists(path) {
+		paths = append(paths, path)
+	}
+	for _, path := range paths {
+		layer, err := readConfigLayer(path)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		if err = checkConfigSchema(layer); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", path, err))
+		}
+	}
+
+	config, err := LoadConfig(opts)
+	if err != nil {
+		return append(problems, err.Error())
+	}
+	if path := findConfigFile(config.Dir, ".gw"); path != "" {
+		if layer, err := readConfigLayer(path); err == nil {
+			if err = checkConfigSchema(layer); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s", path, err))
+			}
+		}
+	}
+
+	if info, err := os.Stat(config.Dir); err == nil {
+		if !info.IsDir() {
+			problems = append(problems, fmt.Sprintf("the working directory \"%s\" is not a directory", config.Dir))
+		}
+	} else if !fileExists(filepath.Dir(config.Dir)) {
+		problems = append(
+			problems,
+			fmt.Sprintf("the working directory \"%s\" cannot be created: its parent does not exist", config.Dir),
+		)
+	}
+
+	for _, source := range config.Sources {
+		if err := checkSource(source); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(config.Workspaces)) {
+		for _, source := range config.Workspaces[name].Sources {
+			if err := checkSource(source); err != nil {
+				problems = append(problems, fmt.Sprintf("workspace %s: %s", name, err))
+			}
+		}
+	}
+	if config.TrashDays < 0 {
+		problems = append(problems, "trash_days must not be negative")
+	}
+	for _, pattern := range config.Done.IgnoreUntracked {
+		if _, err := path.Match(pattern, ""); err != nil {
+			problems = append(problems, fmt.Sprintf("malformed done.ignore_untracked pattern \"%s\"", pattern))
+		}
+	}
+	return problems
+}
+
+func checkConfigSchema(layer map[string]any) error {
+	data, err := json.Marshal(layer)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var config Config
+	return decoder.Decode(&config)
+}
+
+func checkSource(source string) error {
+	if strings.TrimSpace(source) == "" {
+		return fmt.Errorf("empty source")
+	}
+	if _, err := ExpandSource(source, ProjectRef{Host: "host", Owner: "owner", Name: "project"}); err != nil {
+		return fmt.Errorf("malformed source \"%s\": %s", source, err)
+	}
+
+	colon := strings.Index(source, ":")
+	slash := strings.Index(source, "/")
+	isURL := strings.Contains(source, "://")
+	isSCP := colon > 0 && (slash < 0 || colon < slash)
+	if !isURL && !isSCP && !filepath.IsAbs(source) {
+		return fmt.Errorf("malformed source \"%s\": neither a URL, nor an scp-like address, nor an absolute path", source)
+	}
+	return nil
+}
+
+// parseConfigKey splits the key into segments and returns the type of its value.
+func parseConfigKey(key string) ([]string, reflect.Type, error) {
+	segments, err := splitConfigKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyType := reflect.TypeOf(Config{})
+	for _, segment := range segments {
+		switch keyType.Kind() {
+		case reflect.Struct:
+			field, ok := jsonField(keyType, segment)
+			if !ok {
+				return nil, nil, fmt.Errorf("unknown configuration key \"%s\"", key)
+			}
+			keyType = field.Type
+		case reflect.Map:
+			keyType = keyType.Elem()
+		default:
+			return nil, nil, fmt.Errorf("unknown configuration key \"%s\"", key)
+		}
+	}
+	return segments, keyType, nil
+}
+
+// splitConfigKey splits the key on dots. A double-quoted segment may contain dots.
+func splitConfigKey(key string) ([]string, error) {
+	segments := []string{}
+	var segment strings.Builder
+	quoted, wasQuoted := false, false
+	for _, r := range key {
+		switch {
+		case r == '"' && (quoted || segment.Len() == 0 && !wasQuoted):
+			quoted = !quoted
+			wasQuoted = true
+		case quoted:
+			segment.WriteRune(r)
+		case r == '.':
+			if segment.Len() == 0 && !wasQuoted {
+				return nil, fmt.Errorf("malformed configuration key \"%s\": empty segment", key)
+			}
+			segments = append(segments, segment.String())
+			segment.Reset()
+			wasQuoted = false
+		case wasQuoted || r == '"':
+			return nil, fmt.Errorf("malformed configuration key \"%s\": a quote must enclose the whole segment", key)
+		default:
+			segment.WriteRune(r)
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("malformed configuration key \"%s\": unterminated quote", key)
+	}
+	if segment.Len() == 0 && !wasQuoted {
+		return nil, fmt.Errorf("malformed configuration key \"%s\": empty segment", key)
+	}
+	return append(segments, segment.String()), nil
+}
+
+func jsonField(structType reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if tag == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func parseConfigValue(key string, keyType reflect.Type, values []string) (any, error) {
+	if keyType.Kind() == reflect.Slice && keyType.Elem().Kind() == reflect.String {
+		list := make([]any, len(values))
+		for i, value := range values {
+			list[i] = value
+		}
+		return list, nil
+	}
+	if len(values) != 1 {
+		return nil, fmt.Errorf("\"%s\" takes exactly one value", key)
+	}
+
+	value := values[0]
+	switch keyType.Kind() {
+	case reflect.String:
+		return value, nil
+	case reflect.Int:
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("\"%s\" must be an integer: %s", key, err)
+		}
+		return number, nil
+	case reflect.Bool:
+		flag, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("\"%s\" must be a boolean: %s", key, err)
+		}
+		return flag, nil
+	default:
+		return nil, fmt.Errorf("\"%s\" is not a single value, set its nested keys instead", key)
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,146 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigFile(t *testing.T) {
+	for _, ext := range []string{".json", ".yaml", ".toml"} {
+		t.Run(ext, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config"+ext)
+			file, err := OpenConfigFile(path)
+			require.NoError(t, err)
+
+			require.NoError(t, file.Set("dir", []string{"/work"}, false))
+			require.NoError(t, file.Set("sources", []string{"git@github.com:a"}, false))
+			require.NoError(t, file.Set("sources", []string{"https://github.com/b"}, true))
+			require.NoError(t, file.Set("clone.depth", []string{"1"}, false))
+			require.NoError(t, file.Set("clone.single_branch", []string{"true"}, false))
+			require.NoError(t, file.Set("aliases.billing.url", []string{"git@corp:billing.git"}, false))
+			require.NoError(t, file.Set("editor", []string{"vim"}, false))
+			require.NoError(t, file.Unset("editor"))
+			require.NoError(t, file.Save())
+
+			layer, err := readConfigLayer(path)
+			require.NoError(t, err)
+			config, err := decodeConfig(layer)
+			require.NoError(t, err)
+			require.Equal(t, "/work", config.Dir)
+			require.Equal(t, "vi", config.Editor)
+			require.Equal(t, []string{"git@github.com:a", "https://github.com/b"}, config.Sources)
+			require.Equal(t, CloneOpts{Depth: 1, SingleBranch: true}, config.Clone)
+			require.Equal(t, map[string]Alias{"billing": {URL: "git@corp:billing.git"}}, config.Aliases)
+		})
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		file := NewConfigFile(filepath.Join(t.TempDir(), "config.json"))
+
+		require.ErrorContains(t, file.Set("dirr", []string{"/x"}, false), "unknown configuration key")
+		require.ErrorContains(t, file.Set("trash_days", []string{"many"}, false), "must be an integer")
+		require.ErrorContains(t, file.Set("dir", []string{"/a", "/b"}, false), "exactly one value")
+		require.ErrorContains(t, file.Set("editor", []string{"vim"}, true), "not a list")
+		require.ErrorContains(t, file.Set("clone", []string{"x"}, false), "set its nested keys")
+		require.ErrorContains(t, file.Unset("editor"), "is not set")
+		require.ErrorContains(t, file.Set(`source_settings."a.b.clone.depth`, []string{"1"}, false), "unterminated quote")
+		require.ErrorContains(t, file.Set(`source_settings.a"b".clone.depth`, []string{"1"}, false), "whole segment")
+		require.ErrorContains(t, file.Set("clone..depth", []string{"1"}, false), "empty segment")
+	})
+	t.Run("quoted map key", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.toml")
+		file := NewConfigFile(path)
+		key := `source_settings."git@github.com:me".clone.depth`
+
+		require.NoError(t, file.Set(key, []string{"1"}, false))
+		require.NoError(t, file.Set(`source_settings."git@github.com:me".clone.filter`, []string{"blob:none"}, false))
+		require.NoError(t, file.Unset(`source_settings."git@github.com:me".clone.filter`))
+		require.NoError(t, file.Save())
+
+		config, err := LoadConfig(ConfigOpts{Path: path})
+		require.NoError(t, err)
+		require.Equal(t, CloneOpts{Depth: 1}, config.SourceSettings["git@github.com:me"].Clone)
+		value, err := ConfigValue(config, key)
+		require.NoError(t, err)
+		require.Equal(t, float64(1), value)
+
+		require.ErrorContains(t, file.Set("source_settings.git@github.com:me.clone.depth", []string{"1"}, false), "unknown")
+	})
+}
+
+func TestConfigFileWorkspaces(t *testing.T) {
+	file := NewConfigFile(filepath.Join(t.TempDir(), "config.yaml"))
+
+	require.NoError(t, file.AddWorkspace("work", Workspace{Dir: "~/work", Sources: []string{"git@corp:team"}}, true))
+	require.NoError(t, file.AddWorkspace("oss", Workspace{Dir: "~/oss", Editor: "code"}, false))
+	require.ErrorContains(t, file.AddWorkspace("oss", Workspace{Dir: "~/x"}, false), "already exists")
+	require.ErrorContains(t, file.AddWorkspace("a.b", Workspace{Dir: "~/x"}, false), "invalid workspace name")
+	require.ErrorContains(t, file.AddWorkspace("x", Workspace{}, false), "is required")
+	require.NoError(t, file.Save())
+
+	layer, err := readConfigLayer(file.Path)
+	require.NoError(t, err)
+	config, err := decodeConfig(layer)
+	require.NoError(t, err)
+	require.Equal(t, "work", config.DefaultWorkspace)
+	require.Equal(t, map[string]Workspace{
+		"work": {Dir: "~/work", Sources: []string{"git@corp:team"}},
+		"oss":  {Dir: "~/oss", Editor: "code"},
+	}, config.Workspaces)
+
+	require.NoError(t, file.RemoveWorkspace("work"))
+	require.ErrorContains(t, file.RemoveWorkspace("work"), "is not defined")
+	require.NoError(t, file.RemoveWorkspace("oss"))
+	require.Empty(t, file.data)
+}
+
+func TestConfigValue(t *testing.T) {
+	config := NewDefaultConfig()
+	config.Clone.Depth = 5
+
+	value, err := ConfigValue(config, "clone.depth")
+	require.NoError(t, err)
+	require.Equal(t, float64(5), value)
+
+	value, err = ConfigValue(config, "clone.filter")
+	require.NoError(t, err)
+	require.Nil(t, value)
+
+	_, err = ConfigValue(config, "unknown")
+	require.Error(t, err)
+}
+
+func TestValidateConfig(t *testing.T) {
+	_, userDir := setupConfigDirs(t)
+	workDir := t.TempDir()
+	writeFile(t, filepath.Join(userDir, "config.json"), `{
+		"dir": "`+workDir+`",
+		"sources": ["git@github.com:me", "https://github.com/{team}", "github.com"],
+		"edtor": "vim"
+	}`)
+
+	problems := ValidateConfig(ConfigOpts{})
+	require.Len(t, problems, 3)
+	require.Contains(t, problems[0], "unknown field \"edtor\"")
+	require.Contains(t, problems[1], "unknown placeholder")
+	require.Contains(t, problems[2], "neither a URL")
+
+	t.Run("valid", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		writeFile(t, path, "dir: "+filepath.Join(workDir, "new")+"\nsources: [/srv/git]\n")
+		require.Empty(t, ValidateConfig(ConfigOpts{Path: path}))
+	})
+	t.Run("unusable directory", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		dir := filepath.Join(workDir, "file")
+		require.NoError(t, os.WriteFile(dir, nil, 0o644))
+		writeFile(t, path, `{"dir": "`+dir+`"}`)
+
+		problems := ValidateConfig(ConfigOpts{Path: path})
+		require.Len(t, problems, 1)
+		require.Contains(t, problems[0], "is not a directory")
+	})
+}
@@ -0,0 +1,39 @@
+package app
+
+import "fmt"
+
+type ConfigNotFoundError struct {
+	Path string
+}
+
+func (e *ConfigNotFoundError) Error() string {
+	return fmt.Sprintf("missing configuration at %s. Run \"gw config init\" to create it", e.Path)
+}
+
+type ConfigError struct {
+	Path string
+	Op   string
+	Err  error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("failed to %s configuration file at %s: %s", e.Op, e.Path, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+type CacheError struct {
+	Path string
+	Op   string
+	Err  error
+}
+
+func (e *CacheError) Error() string {
+	return fmt.Sprintf("failed to %s the cache file at %s: %s", e.Op, e.Path, e.Err)
+}
+
+func (e *CacheError) Unwrap() error {
+	return e.Err
+}
@@ -1,17 +1,24 @@
 package app
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
+	"syscall"
+	"time"
 )
 
 type FileSystem interface {
 	Exists(path string) (bool, error)
 	Open(path, editor string) error
 	Remove(path string) error
+	Move(src, dst string) error
 	GetGitRepos(dir string) ([]string, error)
+	LastModified(path string) (time.Time, error)
 }
 
 type OSFileSystem struct {
@@ -45,6 +52,20 @@ func (f OSFileSystem) Remove(path string) error {
 	}
 	return nil
 }
+func (f OSFileSystem) Move(src, dst string) error {
+	log.Printf("moving \"%s\" to \"%s\"", src, dst)
+	err := os.Rename(src, dst)
+	if errors.Is(err, syscall.EXDEV) {
+		err = copyTree(src, dst)
+		if err == nil {
+			err = os.RemoveAll(src)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to move \"%s\" to \"%s\": %s", src, dst, err)
+	}
+	return nil
+}
 func (f OSFileSystem) GetGitRepos(dir string) ([]string, error) {
 	log.Printf("gathering GIT directories from \"%s\"", dir)
 	dirs := []string{}
@@ -62,10 +83,87 @@ func (f OSFileSystem) GetGitRepos(dir string) ([]string, error) {
 	return dirs, nil
 }
 
+// LastModified returns the latest modification time of the files under the path. GIT metadata is skipped.
+func (f OSFileSystem) LastModified(path string) (time.Time, error) {
+	var last time.Time
+	err := filepath.WalkDir(path, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Name() == ".git" {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(last) {
+			last = info.ModTime()
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get the modification time of \"%s\": %s", path, err)
+	}
+	return last, nil
+}
+
+// isGitRepo accepts a ".git" file as well since linked worktrees have it instead of the directory.
 func (f OSFileSystem) isGitRepo(path string) bool {
 	gitDir := filepath.Join(path, ".git")
 	info, err := os.Stat(gitDir)
-	return err == nil && info.IsDir()
+	return err == nil && (info.IsDir() || info.Mode().IsRegular())
+}
+
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
 }
 
 func NewOSFileSystem(cmd CMD) OSFileSystem {
@@ -4,7 +4,9 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -13,9 +15,12 @@ type FakeCMD struct {
 	history []map[string]any
 	err     error
 	results []CMDResult
+	mu      sync.Mutex
 }
 
 func (fc *FakeCMD) Run(name string, args []string) (CMDResult, error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
 	fc.history = append(
 		fc.history,
 		map[string]any{
@@ -29,6 +34,8 @@ func (fc *FakeCMD) Run(name string, args []string) (CMDResult, error) {
 }
 
 func (fc *FakeCMD) RunCwd(dir string, name string, args []string) (CMDResult, error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
 	fc.history = append(
 		fc.history,
 		map[string]any{
@@ -42,7 +49,26 @@ func (fc *FakeCMD) RunCwd(dir string, name string, args []string) (CMDResult, er
 	return fc.getNextResult(), fc.err
 }
 
+func (fc *FakeCMD) RunCwdEnv(dir string, env []string, name string, args []string) (CMDResult, error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.history = append(
+		fc.history,
+		map[string]any{
+			"_method": "RunCwdEnv",
+			"dir":     dir,
+			"env":     env,
+			"name":    name,
+			"args":    args,
+		},
+	)
+
+	return fc.getNextResult(), fc.err
+}
+
 func (fc *FakeCMD) ShellRun(name string, args []string) (CMDResult, error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
 	fc.history = append(
 		fc.history,
 		map[string]any{
@@ -144,6 +170,45 @@ func TestRemove(t *testing.T) {
 		require.False(t, exists, "must not exist after removed")
 	})
 }
+func TestMove(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		cmd := &FakeCMD{}
+		fs := NewOSFileSystem(cmd)
+		root := t.TempDir()
+		src := createGitDir(t, root, "src")
+		dst := filepath.Join(root, "dst")
+
+		err := fs.Move(src, dst)
+		require.NoError(t, err)
+		require.NoDirExists(t, src)
+		require.DirExists(t, filepath.Join(dst, ".git"))
+	})
+	t.Run("source does not exist", func(t *testing.T) {
+		fs := NewOSFileSystem(&FakeCMD{})
+		root := t.TempDir()
+		err := fs.Move(filepath.Join(root, "any"), filepath.Join(root, "dst"))
+		require.Error(t, err)
+	})
+}
+
+func TestCopyTree(t *testing.T) {
+	root := t.TempDir()
+	src := createGitDir(t, root, "src")
+	require.NoError(t, os.WriteFile(filepath.Join(src, "file"), []byte("data"), 0o600))
+	require.NoError(t, os.Symlink("file", filepath.Join(src, "link")))
+	dst := filepath.Join(root, "dst")
+
+	err := copyTree(src, dst)
+	require.NoError(t, err)
+	require.DirExists(t, filepath.Join(dst, ".git"))
+	data, err := os.ReadFile(filepath.Join(dst, "file"))
+	require.NoError(t, err)
+	require.Equal(t, "data", string(data))
+	link, err := os.Readlink(filepath.Join(dst, "link"))
+	require.NoError(t, err)
+	require.Equal(t, "file", link)
+}
+
 func TestGetGitRepos(t *testing.T) {
 	t.Run("ok", func(t *testing.T) {
 		cmd := &FakeCMD{}
@@ -153,10 +218,37 @@ func TestGetGitRepos(t *testing.T) {
 		createGitDir(t, dir, "one")
 		gitDir2 := createGitDir(t, dir, "two")
 		createGitDir(t, gitDir2, "three")
+		worktree := filepath.Join(dir, "worktree")
+		require.NoError(t, os.MkdirAll(worktree, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: /x"), 0o644))
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "plain"), 0755))
 
 		dirs, err := fs.GetGitRepos(dir)
 		require.NoError(t, err)
 		require.Empty(t, cmd.history)
-		require.Equal(t, dirs, []string{"one", "two"})
+		require.Equal(t, dirs, []string{"one", "two", "worktree"})
 	})
 }
+
+func TestLastModified(t *testing.T) {
+	fs := NewOSFileSystem(&FakeCMD{})
+	dir := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour)
+	recent := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "src"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "src", "main.go"), []byte("x"), 0o644))
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "src", "main.go"), recent, recent))
+	createGitDir(t, dir, "")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".git", "FETCH_HEAD"), []byte("x"), 0o644))
+	for _, path := range []string{filepath.Join(dir, "src"), dir} {
+		require.NoError(t, os.Chtimes(path, old, old))
+	}
+
+	modified, err := fs.LastModified(dir)
+	require.NoError(t, err)
+	require.True(t, recent.Equal(modified), "expected %s, got %s", recent, modified)
+
+	_, err = fs.LastModified(filepath.Join(dir, "missing"))
+	require.Error(t, err)
+}
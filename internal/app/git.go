@@ -1,48 +1,115 @@
 package app
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Git interface {
 	GetProjectState(path string) (GitProjectState, error)
-	Clone(source, destination string) error
+	GetWorktreeState(path string) (GitProjectState, error)
+	Clone(source, destination string, opts CloneOpts) error
+	Hydrate(path string) error
+	GetCurrentBranch(path string) (string, error)
+	GetBranchStatus(path string) (GitBranchStatus, error)
+	GetStashCount(path string) (int, error)
+	GetUnpushedTagCount(path string) (int, error)
+	MarkMergedUpstream(path string, commits []GitCommit) ([]GitCommit, error)
+	GetWorktrees(path string) ([]GitWorktree, error)
+	AddWorktree(path, worktreePath, branch string) error
+	RemoveWorktree(path, worktreePath string) error
+	Fetch(path string) error
+	FastForward(path string) error
+	UpdateDefaultBranch(path string) (GitDefaultBranchUpdate, error)
+	CheckRemote(url string) error
+	GetActivity(path string) (GitActivity, error)
 }
 
-type GitProjectState struct {
-	Stashes string
-	Tags    string
-	Commits string
-	Status  string
+// GitActivity is zero-valued for the things that never happened, e.g. a repository was never fetched.
+type GitActivity struct {
+	LastCommit time.Time
+	LastFetch  time.Time
 }
 
-func (state GitProjectState) String() string {
-	output := strings.Builder{}
-	if state.Stashes != "" {
-		output.WriteString(fmt.Sprintf("Stashes:\n%s", state.Stashes))
-	}
-	if state.Tags != "" {
-		output.WriteString(fmt.Sprintf("\nTags:\n%s", state.Tags))
+// GitDefaultBranchUpdate tells whether the local default branch was fast-forwarded to origin. A diverged branch is
+// left as is.
+type GitDefaultBranchUpdate struct {
+	Branch   string
+	Updated  bool
+	Diverged bool
+}
+
+type CloneOpts struct {
+	Depth             int    `json:"depth,omitempty"`
+	Filter            string `json:"filter,omitempty"`
+	SingleBranch      bool   `json:"single_branch,omitempty"`
+	NoTags            bool   `json:"no_tags,omitempty"`
+	RecurseSubmodules bool   `json:"recurse_submodules,omitempty"`
+	// Branch is chosen per clone, so it is not configurable
+	Branch string `json:"-"`
+}
+
+func (opts CloneOpts) Merge(other CloneOpts) CloneOpts {
+	if other.Depth != 0 {
+		opts.Depth = other.Depth
 	}
-	if state.Commits != "" {
-		output.WriteString(fmt.Sprintf("\nCommits:\n%s", state.Commits))
+	if other.Filter != "" {
+		opts.Filter = other.Filter
 	}
-	if state.Status != "" {
-		output.WriteString(fmt.Sprintf("\nStatus:\n%s", state.Status))
+	opts.SingleBranch = opts.SingleBranch || other.SingleBranch
+	opts.NoTags = opts.NoTags || other.NoTags
+	opts.RecurseSubmodules = opts.RecurseSubmodules || other.RecurseSubmodules
+	if other.Branch != "" {
+		opts.Branch = other.Branch
 	}
-	return output.String()
+	return opts
 }
 
-func (state GitProjectState) Clean() bool {
-	fields := []string{state.Stashes, state.Tags, state.Commits, state.Status}
-	for _, field := range fields {
-		if field != "" {
-			return false
-		}
+func (opts CloneOpts) args() []string {
+	args := []string{}
+	if opts.Depth > 0 {
+		args = append(args, fmt.Sprintf("--depth=%d", opts.Depth))
+	}
+	if opts.Filter != "" {
+		args = append(args, fmt.Sprintf("--filter=%s", opts.Filter))
 	}
-	return true
+	if opts.SingleBranch {
+		args = append(args, "--single-branch")
+	}
+	if opts.NoTags {
+		args = append(args, "--no-tags")
+	}
+	if opts.RecurseSubmodules {
+		args = append(args, "--recurse-submodules")
+	}
+	if opts.Branch != "" {
+		args = append(args, fmt.Sprintf("--branch=%s", opts.Branch))
+	}
+	return args
+}
+
+type GitBranchStatus struct {
+	Branch    string
+	Upstream  string
+	Ahead     int
+	Behind    int
+	Modified  int
+	Untracked int
+}
+
+type GitWorktree struct {
+	Path     string
+	Branch   string
+	Main     bool
+	Current  bool
+	Prunable bool
 }
 
 type GitAPI struct {
@@ -50,6 +117,38 @@ type GitAPI struct {
 }
 
 func (g GitAPI) GetProjectState(path string) (GitProjectState, error) {
+	return g.getProjectState(path, false)
+}
+
+// GetWorktreeState checks only what a linked worktree owns: its changes, its submodules and the commits of a detached
+// HEAD no ref reaches. Branches, stashes and tags live in the main repository and stay with it.
+func (g GitAPI) GetWorktreeState(path string) (GitProjectState, error) {
+	log.Printf("getting Git status for the worktree \"%s\"", path)
+	result, err := g.cmd.RunCwd(
+		path,
+		"git",
+		[]string{"log", "HEAD", "--not", "--branches", "--tags", "--remotes", "--source", "--format=%H%x00%S%x00%s"},
+	)
+	if err != nil {
+		return GitProjectState{}, fmt.Errorf("failed to get commits for \"%s\": %s", path, err)
+	}
+	commits, err := parseCommits(result.Stdout)
+	if err != nil {
+		return GitProjectState{}, fmt.Errorf("failed to get commits for \"%s\": %s", path, err)
+	}
+	changes, err := g.getGitChanges(path)
+	if err != nil {
+		return GitProjectState{}, fmt.Errorf("failed to get status for \"%s\": %s", path, err)
+	}
+	submodules, err := g.getSubmodules(path)
+	if err != nil {
+		return GitProjectState{}, fmt.Errorf("failed to get submodules for \"%s\": %s", path, err)
+	}
+	return GitProjectState{Commits: commits, Changes: changes, Submodules: submodules}, nil
+}
+
+// getProjectState includes commits of a detached HEAD for submodules as they are rarely on a branch.
+func (g GitAPI) getProjectState(path string, submodule bool) (GitProjectState, error) {
 	log.Printf("getting Git status for \"%s\"", path)
 	stashes, err := g.getGitStashes(path)
 	if err != nil {
@@ -59,32 +158,34 @@ func (g GitAPI) GetProjectState(path string) (GitProjectState, error) {
 	if err != nil {
 		return GitProjectState{}, fmt.Errorf("failed to get tags for \"%s\": %s", path, err)
 	}
-	commits, err := g.getGitCommits(path)
+	commits, err := g.getGitCommits(path, submodule)
 	if err != nil {
 		return GitProjectState{}, fmt.Errorf("failed to get commits for \"%s\": %s", path, err)
 	}
-	status, err := g.getGitStatus(path)
+	changes, err := g.getGitChanges(path)
 	if err != nil {
 		return GitProjectState{}, fmt.Errorf("failed to get status for \"%s\": %s", path, err)
 	}
+	submodules, err := g.getSubmodules(path)
+	if err != nil {
+		return GitProjectState{}, fmt.Errorf("failed to get submodules for \"%s\": %s", path, err)
+	}
 
 	return GitProjectState{
-		Stashes: stashes,
-		Tags:    tags,
-		Commits: commits,
-		Status:  status,
+		Stashes:    stashes,
+		Tags:       tags,
+		Commits:    commits,
+		Changes:    changes,
+		Submodules: submodules,
 	}, nil
 }
 
-func (g GitAPI) Clone(source, destination string) error {
+func (g GitAPI) Clone(source, destination string, opts CloneOpts) error {
 	log.Printf("cloning \"%s\" to \"%s\"", source, destination)
+	args := append([]string{"clone"}, opts.args()...)
 	_, err := g.cmd.Run(
 		"git",
-		[]string{
-			"clone",
-			source,
-			destination,
-		},
+		append(args, source, destination),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to clone \"%s\" to \"%s\": %s", source, destination, err)
@@ -93,43 +194,506 @@ func (g GitAPI) Clone(source, destination string) error {
 	return nil
 }
 
-func (g GitAPI) getGitStashes(path string) (string, error) {
-	result, err := g.cmd.RunCwd(path, "git", []string{"stash", "list"})
+func (g GitAPI) Hydrate(path string) error {
+	log.Printf("hydrating \"%s\"", path)
+	result, err := g.cmd.RunCwd(path, "git", []string{"rev-parse", "--is-shallow-repository"})
 	if err != nil {
-		return "", err
+		return fmt.Errorf("failed to check whether \"%s\" is shallow: %s", path, err)
+	}
+	shallow := strings.TrimSpace(result.Stdout) == "true"
+
+	filter, err := g.getConfig(path, "remote.origin.partialclonefilter")
+	if err != nil {
+		return fmt.Errorf("failed to check whether \"%s\" is a partial clone: %s", path, err)
+	}
+	tagOpt, err := g.getConfig(path, "remote.origin.tagopt")
+	if err != nil {
+		return fmt.Errorf("failed to get tag options of \"%s\": %s", path, err)
+	}
+
+	commands := [][]string{{"remote", "set-branches", "origin", "*"}}
+	if filter != "" {
+		commands = append(commands, []string{"config", "--unset", "remote.origin.partialclonefilter"})
+	}
+	if tagOpt != "" {
+		commands = append(commands, []string{"config", "--unset", "remote.origin.tagopt"})
+	}
+	fetch := []string{"fetch", "--tags"}
+	if shallow {
+		fetch = append(fetch, "--unshallow")
+	}
+	if filter != "" {
+		fetch = append(fetch, "--refetch")
+	}
+	commands = append(commands, append(fetch, "origin"))
+	if filter != "" {
+		commands = append(commands, []string{"config", "--unset", "remote.origin.promisor"})
+	}
+
+	for _, args := range commands {
+		if _, err := g.cmd.RunCwd(path, "git", args); err != nil {
+			return fmt.Errorf("failed to hydrate \"%s\": %s", path, err)
+		}
+	}
+	return nil
+}
+
+func (g GitAPI) Fetch(path string) error {
+	log.Printf("fetching \"%s\"", path)
+	if _, err := g.cmd.RunCwd(path, "git", []string{"fetch", "--all", "--prune"}); err != nil {
+		return fmt.Errorf("failed to fetch \"%s\": %s", path, err)
 	}
-	return result.Stdout, nil
+	return nil
 }
 
-func (g GitAPI) getGitTags(path string) (string, error) {
-	result, err := g.cmd.RunCwd(path, "git", []string{"push", "--tags", "--dry-run"})
+// CheckRemote fails if the repository at the URL cannot be reached. Credentials are never prompted for.
+func (g GitAPI) CheckRemote(url string) error {
+	_, err := g.cmd.RunCwdEnv("", []string{"GIT_TERMINAL_PROMPT=0"}, "git", []string{"ls-remote", url, "HEAD"})
 	if err != nil {
-		return "", err
+		return fmt.Errorf("failed to reach \"%s\": %s", url, err)
+	}
+	return nil
+}
+
+// GetActivity returns the time of the latest commit on the local branches and the time of the last fetch.
+func (g GitAPI) GetActivity(path string) (GitActivity, error) {
+	activity := GitActivity{}
+	result, err := g.cmd.RunCwd(path, "git", []string{"log", "-1", "--branches", "--format=%ct"})
+	if err != nil {
+		return activity, fmt.Errorf("failed to get the last commit of \"%s\": %s", path, err)
+	}
+	if stamp := strings.TrimSpace(result.Stdout); stamp != "" {
+		seconds, err := strconv.ParseInt(stamp, 10, 64)
+		if err != nil {
+			return activity, fmt.Errorf("failed to parse the last commit time of \"%s\": %s", path, err)
+		}
+		activity.LastCommit = time.Unix(seconds, 0)
+	}
+
+	// Linked worktrees share FETCH_HEAD of the main repository
+	result, err = g.cmd.RunCwd(path, "git", []string{"rev-parse", "--git-common-dir"})
+	if err != nil {
+		return activity, fmt.Errorf("failed to get the GIT directory of \"%s\": %s", path, err)
+	}
+	gitDir := strings.TrimSpace(result.Stdout)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(path, gitDir)
 	}
-	if !strings.Contains(result.Stderr, "new tag") {
-		return "", nil
+	if info, err := os.Stat(filepath.Join(gitDir, "FETCH_HEAD")); err == nil {
+		activity.LastFetch = info.ModTime()
 	}
-	return result.Stderr, nil
+	return activity, nil
 }
 
-func (g GitAPI) getGitCommits(path string) (string, error) {
-	result, err := g.cmd.RunCwd(
+func (g GitAPI) FastForward(path string) error {
+	log.Printf("fast-forwarding \"%s\"", path)
+	if _, err := g.cmd.RunCwd(path, "git", []string{"merge", "--ff-only", "@{u}"}); err != nil {
+		return fmt.Errorf("failed to fast-forward \"%s\": %s", path, err)
+	}
+	return nil
+}
+
+// UpdateDefaultBranch fast-forwards the local default branch to origin without checking it out. Missing, checked out
+// and ahead branches are left as is.
+func (g GitAPI) UpdateDefaultBranch(path string) (GitDefaultBranchUpdate, error) {
+	upstream, err := g.getDefaultUpstream(path)
+	if err != nil {
+		return GitDefaultBranchUpdate{}, fmt.Errorf("failed to get the default branch for \"%s\": %s", path, err)
+	}
+	update := GitDefaultBranchUpdate{Branch: strings.TrimPrefix(upstream, "refs/remotes/origin/")}
+	ref := "refs/heads/" + update.Branch
+
+	current, err := g.GetCurrentBranch(path)
+	if err != nil {
+		return update, err
+	}
+	if current == update.Branch {
+		return update, nil
+	}
+
+	before, err := g.getRef(path, ref)
+	if err != nil || before == "" {
+		return update, err
+	}
+	result, err := g.cmd.RunCwd(path, "git", []string{"merge-base", ref, upstream})
+	if err != nil {
+		return update, fmt.Errorf("failed to compare \"%s\" with \"%s\": %s", ref, upstream, err)
+	}
+	if base := strings.TrimSpace(result.Stdout); base != before {
+		upstreamHash, err := g.getRef(path, upstream)
+		if err != nil {
+			return update, err
+		}
+		update.Diverged = base != upstreamHash
+		return update, nil
+	}
+
+	_, err = g.cmd.RunCwd(path, "git", []string{"fetch", ".", upstream + ":" + ref})
+	if err != nil {
+		return update, fmt.Errorf("failed to fast-forward \"%s\" of \"%s\": %s", update.Branch, path, err)
+	}
+	after, err := g.getRef(path, ref)
+	if err != nil {
+		return update, err
+	}
+	update.Updated = after != before
+	return update, nil
+}
+
+func (g GitAPI) GetCurrentBranch(path string) (string, error) {
+	result, err := g.cmd.RunCwd(path, "git", []string{"rev-parse", "--abbrev-ref", "HEAD"})
+	if err != nil {
+		return "", fmt.Errorf("failed to get current branch for \"%s\": %s", path, err)
+	}
+	return strings.TrimSpace(result.Stdout), nil
+}
+
+func (g GitAPI) GetBranchStatus(path string) (GitBranchStatus, error) {
+	result, err := g.cmd.RunCwd(path, "git", []string{"status", "--porcelain=v2", "--branch"})
+	if err != nil {
+		return GitBranchStatus{}, fmt.Errorf("failed to get branch status for \"%s\": %s", path, err)
+	}
+
+	status, err := parseBranchStatus(result.Stdout)
+	if err != nil {
+		return GitBranchStatus{}, fmt.Errorf("failed to parse branch status for \"%s\": %s", path, err)
+	}
+	return status, nil
+}
+
+func (g GitAPI) GetStashCount(path string) (int, error) {
+	stashes, err := g.getGitStashes(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get stashes for \"%s\": %s", path, err)
+	}
+	return len(stashes), nil
+}
+
+func (g GitAPI) GetUnpushedTagCount(path string) (int, error) {
+	tags, err := g.getGitTags(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get tags for \"%s\": %s", path, err)
+	}
+	return len(tags), nil
+}
+
+// MarkMergedUpstream marks the commits whose changes are already in the default branch of origin, i.e. the
+// commits of the branches which were rebased or squashed when merged upstream.
+func (g GitAPI) MarkMergedUpstream(path string, commits []GitCommit) ([]GitCommit, error) {
+	upstream, err := g.getDefaultUpstream(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the default branch for \"%s\": %s", path, err)
+	}
+
+	merged := map[string]bool{}
+	for _, branch := range commitBranches(commits) {
+		result, err := g.cmd.RunCwd(path, "git", []string{"cherry", upstream, "refs/heads/" + branch})
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare \"%s\" with \"%s\": %s", branch, upstream, err)
+		}
+		pending := false
+		// A commit shared with an already checked branch stays merged
+		for hash, equivalent := range parseCherry(result.Stdout) {
+			merged[hash] = merged[hash] || equivalent
+			pending = pending || !equivalent
+		}
+		if !pending {
+			continue
+		}
+
+		squashed, err := g.isSquashMerged(path, upstream, branch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check if \"%s\" was squash-merged: %s", branch, err)
+		}
+		if squashed {
+			for _, commit := range commits {
+				if commit.Branch == branch {
+					merged[commit.Hash] = true
+				}
+			}
+		}
+	}
+
+	marked := make([]GitCommit, len(commits))
+	for i, commit := range commits {
+		commit.MergedUpstream = merged[commit.Hash]
+		marked[i] = commit
+	}
+	return marked, nil
+}
+
+// GetWorktrees lists all worktrees of the repository, the main one first. The one containing the path is marked
+// as current.
+func (g GitAPI) GetWorktrees(path string) ([]GitWorktree, error) {
+	result, err := g.cmd.RunCwd(path, "git", []string{"rev-parse", "--show-toplevel"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the top level directory of \"%s\": %s", path, err)
+	}
+	toplevel := strings.TrimSpace(result.Stdout)
+
+	result, err = g.cmd.RunCwd(path, "git", []string{"worktree", "list", "--porcelain"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worktrees of \"%s\": %s", path, err)
+	}
+	worktrees := parseWorktrees(result.Stdout)
+	for i := range worktrees {
+		worktrees[i].Current = worktrees[i].Path == toplevel
+	}
+	return worktrees, nil
+}
+
+func (g GitAPI) AddWorktree(path, worktreePath, branch string) error {
+	log.Printf("adding a worktree of \"%s\" for \"%s\" to \"%s\"", path, branch, worktreePath)
+	_, err := g.cmd.RunCwd(path, "git", []string{"worktree", "add", worktreePath, branch})
+	if err == nil {
+		return nil
+	}
+
+	log.Printf("%s\nCreating a new branch...", err)
+	_, err = g.cmd.RunCwd(path, "git", []string{"worktree", "add", "-b", branch, worktreePath})
+	if err != nil {
+		return fmt.Errorf("failed to add a worktree of \"%s\" for \"%s\": %s", path, branch, err)
+	}
+	return nil
+}
+
+// RemoveWorktree deletes the linked worktree along with its metadata in the main repository. It is forced as the
+// worktree is checked by the caller.
+func (g GitAPI) RemoveWorktree(path, worktreePath string) error {
+	log.Printf("removing the worktree \"%s\" of \"%s\"", worktreePath, path)
+	_, err := g.cmd.RunCwd(path, "git", []string{"worktree", "remove", "--force", worktreePath})
+	if err != nil {
+		return fmt.Errorf("failed to remove the worktree \"%s\" of \"%s\": %s", worktreePath, path, err)
+	}
+	return nil
+}
+
+func (g GitAPI) getDefaultUpstream(path string) (string, error) {
+	result, err := g.cmd.RunCwd(path, "git", []string{"symbolic-ref", "--quiet", "refs/remotes/origin/HEAD"})
+	if err == nil {
+		return strings.TrimSpace(result.Stdout), nil
+	}
+
+	result, err = g.cmd.RunCwd(
 		path,
 		"git",
-		[]string{"log", "--branches", "--not", "--remotes", "--decorate", "--oneline"},
+		[]string{"for-each-ref", "--format=%(refname)", "refs/remotes/origin/main", "refs/remotes/origin/master"},
 	)
 	if err != nil {
 		return "", err
 	}
-	return result.Stdout, nil
+	refs := nonEmptyLines(result.Stdout)
+	if len(refs) == 0 {
+		return "", errors.New("origin has neither HEAD nor main nor master")
+	}
+	return refs[0], nil
+}
+
+// isSquashMerged checks whether upstream has a commit with the same patch as the whole branch since its merge base.
+// The patches are compared by their patch IDs, so nothing is written to the repository.
+func (g GitAPI) isSquashMerged(path, upstream, branch string) (bool, error) {
+	ref := "refs/heads/" + branch
+	result, err := g.cmd.RunCwd(path, "git", []string{"merge-base", upstream, ref})
+	if err != nil {
+		return false, err
+	}
+	base := strings.TrimSpace(result.Stdout)
+
+	squashed, err := g.getPatchIDs(path, `git diff --no-color "$1" "$2"`, base, ref)
+	if err != nil || len(squashed) == 0 {
+		return false, err
+	}
+	merged, err := g.getPatchIDs(path, `git log -p --no-color "$1..$2"`, base, upstream)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(merged, squashed[0]), nil
+}
+
+// getPatchIDs pipes the patches printed by the git command to "git patch-id". The command gets the revisions as
+// positional parameters.
+func (g GitAPI) getPatchIDs(path, command string, revisions ...string) ([]string, error) {
+	args := append([]string{"-c", command + " | git patch-id --stable", "sh"}, revisions...)
+	result, err := g.cmd.RunCwd(path, "sh", args)
+	if err != nil {
+		return nil, err
+	}
+	return parsePatchIDs(result.Stdout), nil
 }
 
-func (g GitAPI) getGitStatus(path string) (string, error) {
-	result, err := g.cmd.RunCwd(path, "git", []string{"status", "--short"})
+func (g GitAPI) getGitStashes(path string) ([]GitStash, error) {
+	result, err := g.cmd.RunCwd(path, "git", []string{"stash", "list", "--format=%gd%x00%ct%x00%gs"})
+	if err != nil {
+		return nil, err
+	}
+	return parseStashes(result.Stdout)
+}
+
+func (g GitAPI) getGitTags(path string) ([]string, error) {
+	result, err := g.cmd.RunCwd(path, "git", []string{"push", "--tags", "--dry-run", "--porcelain"})
+	if err != nil {
+		return nil, err
+	}
+	return parseNewTags(result.Stdout), nil
+}
+
+func (g GitAPI) getGitCommits(path string, withHead bool) ([]GitCommit, error) {
+	args := []string{"log", "--branches"}
+	if withHead {
+		args = append(args, "HEAD")
+	}
+	args = append(args, "--not", "--remotes", "--source", "--format=%H%x00%S%x00%s")
+	result, err := g.cmd.RunCwd(path, "git", args)
+	if err != nil {
+		return nil, err
+	}
+	return parseCommits(result.Stdout)
+}
+
+func (g GitAPI) getGitChanges(path string) ([]GitFileChange, error) {
+	result, err := g.cmd.RunCwd(path, "git", []string{"status", "--porcelain=v1", "-z"})
+	if err != nil {
+		return nil, err
+	}
+	return parseChanges(result.Stdout)
+}
+
+func (g GitAPI) getSubmodules(path string) ([]SubmoduleState, error) {
+	result, err := g.cmd.RunCwd(path, "git", []string{"submodule", "status"})
+	if err != nil {
+		return nil, err
+	}
+
+	var submodules []SubmoduleState
+	for _, subPath := range parseSubmodulePaths(result.Stdout) {
+		state, err := g.getProjectState(filepath.Join(path, subPath), true)
+		if err != nil {
+			return nil, err
+		}
+		submodules = append(submodules, SubmoduleState{Path: subPath, State: state})
+	}
+	return submodules, nil
+}
+
+func (g GitAPI) getRef(path, ref string) (string, error) {
+	result, err := g.cmd.RunCwd(path, "git", []string{"for-each-ref", "--format=%(objectname)", ref})
+	if err != nil {
+		return "", fmt.Errorf("failed to get \"%s\" of \"%s\": %s", ref, path, err)
+	}
+	return strings.TrimSpace(result.Stdout), nil
+}
+
+func (g GitAPI) getConfig(path, key string) (string, error) {
+	result, err := g.cmd.RunCwd(path, "git", []string{"config", "--default", "", "--get", key})
 	if err != nil {
 		return "", err
 	}
-	return result.Stdout, nil
+	return strings.TrimSpace(result.Stdout), nil
+}
+
+func commitBranches(commits []GitCommit) []string {
+	branches := []string{}
+	for _, commit := range commits {
+		if !slices.Contains(branches, commit.Branch) {
+			branches = append(branches, commit.Branch)
+		}
+	}
+	return branches
+}
+
+// parseCherry maps the commits listed by "git cherry" to whether upstream has an equivalent change.
+func parseCherry(output string) map[string]bool {
+	commits := map[string]bool{}
+	for _, line := range nonEmptyLines(output) {
+		sign, hash, found := strings.Cut(strings.TrimSpace(line), " ")
+		if !found {
+			continue
+		}
+		commits[hash] = sign == "-"
+	}
+	return commits
+}
+
+// parsePatchIDs returns the patch IDs printed by "git patch-id", each followed by the commit.
+func parsePatchIDs(output string) []string {
+	ids := []string{}
+	for _, line := range nonEmptyLines(output) {
+		id, _, _ := strings.Cut(strings.TrimSpace(line), " ")
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// parseSubmodulePaths returns the paths of initialized submodules. Uninitialized ones are prefixed with "-".
+func parseSubmodulePaths(output string) []string {
+	var paths []string
+	for _, line := range nonEmptyLines(output) {
+		if strings.HasPrefix(line, "-") {
+			continue
+		}
+		_, rest, found := strings.Cut(strings.TrimSpace(line[1:]), " ")
+		if !found {
+			continue
+		}
+		if strings.HasSuffix(rest, ")") {
+			if i := strings.LastIndex(rest, " ("); i >= 0 {
+				rest = rest[:i]
+			}
+		}
+		paths = append(paths, rest)
+	}
+	return paths
+}
+
+func parseWorktrees(output string) []GitWorktree {
+	var worktrees []GitWorktree
+	for _, line := range strings.Split(output, "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "worktree":
+			worktrees = append(worktrees, GitWorktree{Path: value, Main: len(worktrees) == 0})
+		case "branch":
+			if len(worktrees) > 0 {
+				worktrees[len(worktrees)-1].Branch = strings.TrimPrefix(value, "refs/heads/")
+			}
+		case "prunable":
+			if len(worktrees) > 0 {
+				worktrees[len(worktrees)-1].Prunable = true
+			}
+		}
+	}
+	return worktrees
+}
+
+func parseBranchStatus(output string) (GitBranchStatus, error) {
+	status := GitBranchStatus{}
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case strings.HasPrefix(line, "# branch.head "):
+			status.Branch = strings.TrimPrefix(line, "# branch.head ")
+		case strings.HasPrefix(line, "# branch.upstream "):
+			status.Upstream = strings.TrimPrefix(line, "# branch.upstream ")
+		case strings.HasPrefix(line, "# branch.ab "):
+			var err error
+			fields := strings.Fields(strings.TrimPrefix(line, "# branch.ab "))
+			if len(fields) != 2 {
+				return status, fmt.Errorf("unexpected ahead/behind line \"%s\"", line)
+			}
+			status.Ahead, err = strconv.Atoi(strings.TrimPrefix(fields[0], "+"))
+			if err != nil {
+				return status, fmt.Errorf("unexpected ahead/behind line \"%s\": %s", line, err)
+			}
+			status.Behind, err = strconv.Atoi(strings.TrimPrefix(fields[1], "-"))
+			if err != nil {
+				return status, fmt.Errorf("unexpected ahead/behind line \"%s\": %s", line, err)
+			}
+		case strings.HasPrefix(line, "1 "), strings.HasPrefix(line, "2 "), strings.HasPrefix(line, "u "):
+			status.Modified++
+		case strings.HasPrefix(line, "? "):
+			status.Untracked++
+		}
+	}
+	return status, nil
 }
 
 func NewGitAPI(cmd CMD) GitAPI {
@@ -2,7 +2,11 @@ package app
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -14,7 +18,7 @@ func TestClone(t *testing.T) {
 
 		source := "source"
 		destination := "dest"
-		err := git.Clone(source, destination)
+		err := git.Clone(source, destination, CloneOpts{})
 		require.NoError(t, err)
 		require.Equal(
 			t,
@@ -33,9 +37,87 @@ func TestClone(t *testing.T) {
 			err: errors.New("cmd err"),
 		}
 		git := NewGitAPI(cmd)
-		err := git.Clone("s", "d")
+		err := git.Clone("s", "d", CloneOpts{})
 		require.Error(t, err)
 	})
+	t.Run("options", func(t *testing.T) {
+		cmd := &FakeCMD{}
+		git := NewGitAPI(cmd)
+
+		err := git.Clone(
+			"s",
+			"d",
+			CloneOpts{Depth: 1, Filter: "blob:none", SingleBranch: true, NoTags: true, RecurseSubmodules: true},
+		)
+		require.NoError(t, err)
+		require.Equal(
+			t,
+			[]string{
+				"clone", "--depth=1", "--filter=blob:none", "--single-branch", "--no-tags", "--recurse-submodules", "s", "d",
+			},
+			cmd.history[0]["args"],
+		)
+	})
+}
+
+func TestCloneOptsMerge(t *testing.T) {
+	base := CloneOpts{Depth: 1, Filter: "tree:0", SingleBranch: true}
+	merged := base.Merge(CloneOpts{Depth: 5, NoTags: true})
+	require.Equal(t, CloneOpts{Depth: 5, Filter: "tree:0", SingleBranch: true, NoTags: true}, merged)
+	require.Equal(t, base, base.Merge(CloneOpts{}))
+}
+
+func TestHydrateGit(t *testing.T) {
+	tests := map[string]struct {
+		cmdResults []CMDResult
+		expected   [][]string
+	}{
+		"full clone": {
+			cmdResults: []CMDResult{{Stdout: "false\n"}, {}, {}},
+			expected: [][]string{
+				{"remote", "set-branches", "origin", "*"},
+				{"fetch", "--tags", "origin"},
+			},
+		},
+		"shallow single branch without tags": {
+			cmdResults: []CMDResult{{Stdout: "true\n"}, {}, {Stdout: "--no-tags\n"}},
+			expected: [][]string{
+				{"remote", "set-branches", "origin", "*"},
+				{"config", "--unset", "remote.origin.tagopt"},
+				{"fetch", "--tags", "--unshallow", "origin"},
+			},
+		},
+		"partial": {
+			cmdResults: []CMDResult{{Stdout: "false\n"}, {Stdout: "blob:none\n"}, {}},
+			expected: [][]string{
+				{"remote", "set-branches", "origin", "*"},
+				{"config", "--unset", "remote.origin.partialclonefilter"},
+				{"fetch", "--tags", "--refetch", "origin"},
+				{"config", "--unset", "remote.origin.promisor"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cmd := &FakeCMD{results: test.cmdResults}
+			git := NewGitAPI(cmd)
+
+			err := git.Hydrate("proj/path")
+			require.NoError(t, err)
+
+			actual := [][]string{}
+			for _, call := range cmd.history[3:] {
+				actual = append(actual, call["args"].([]string))
+			}
+			require.Equal(t, test.expected, actual)
+		})
+	}
+	t.Run("cmd error", func(t *testing.T) {
+		cmd := &FakeCMD{err: errors.New("cmd err")}
+		git := NewGitAPI(cmd)
+		require.Error(t, git.Hydrate("proj/path"))
+	})
 }
 
 func TestGetProjectState(t *testing.T) {
@@ -53,25 +135,31 @@ func TestGetProjectState(t *testing.T) {
 					"_method": "RunCwd",
 					"dir":     "proj/path",
 					"name":    "git",
-					"args":    []string{"stash", "list"},
+					"args":    []string{"stash", "list", "--format=%gd%x00%ct%x00%gs"},
 				},
 				{
 					"_method": "RunCwd",
 					"dir":     "proj/path",
 					"name":    "git",
-					"args":    []string{"push", "--tags", "--dry-run"},
+					"args":    []string{"push", "--tags", "--dry-run", "--porcelain"},
 				},
 				{
 					"_method": "RunCwd",
 					"dir":     "proj/path",
 					"name":    "git",
-					"args":    []string{"log", "--branches", "--not", "--remotes", "--decorate", "--oneline"},
+					"args":    []string{"log", "--branches", "--not", "--remotes", "--source", "--format=%H%x00%S%x00%s"},
 				},
 				{
 					"_method": "RunCwd",
 					"dir":     "proj/path",
 					"name":    "git",
-					"args":    []string{"status", "--short"},
+					"args":    []string{"status", "--porcelain=v1", "-z"},
+				},
+				{
+					"_method": "RunCwd",
+					"dir":     "proj/path",
+					"name":    "git",
+					"args":    []string{"submodule", "status"},
 				},
 			},
 		)
@@ -82,41 +170,63 @@ func TestGetProjectState(t *testing.T) {
 		require.True(t, state.Clean(), "state is not clean: %v", state)
 	})
 
+	stashOutput := "stash@{0}\x001700000000\x00On main: wip\n"
+	tagsOutput := "To origin\n=\trefs/tags/v1:refs/tags/v1\t[up to date]\n*\trefs/tags/v2:refs/tags/v2\t[new tag]\nDone\n"
+	commitsOutput := "1234567890\x00feature\x00Add feature\n"
+	changesOutput := " M file.go\x00R  new.go\x00old.go\x00?? untracked.go\x00"
+
 	tests := map[string]struct {
 		cmdResults []CMDResult
+		expected   GitProjectState
 	}{
 		"stashes": {
 			cmdResults: []CMDResult{
-				{Stdout: "stash"},
+				{Stdout: stashOutput},
+			},
+			expected: GitProjectState{
+				Stashes: []GitStash{{Ref: "stash@{0}", Message: "On main: wip", Time: time.Unix(1700000000, 0)}},
 			},
 		},
 		"tags": {
 			cmdResults: []CMDResult{
 				{},
-				{Stderr: "new tag"},
+				{Stdout: tagsOutput},
+			},
+			expected: GitProjectState{
+				Tags: []string{"v2"},
 			},
 		},
 		"commits": {
 			cmdResults: []CMDResult{
 				{},
 				{},
-				{Stdout: "commit"},
+				{Stdout: commitsOutput},
+			},
+			expected: GitProjectState{
+				Commits: []GitCommit{{Hash: "1234567890", Branch: "feature", Subject: "Add feature"}},
 			},
 		},
-		"status": {
+		"changes": {
 			cmdResults: []CMDResult{
 				{},
 				{},
 				{},
-				{Stdout: "status"},
+				{Stdout: changesOutput},
+			},
+			expected: GitProjectState{
+				Changes: []GitFileChange{
+					{Status: " M", Path: "file.go"},
+					{Status: "R ", Path: "new.go", OrigPath: "old.go"},
+					{Status: "??", Path: "untracked.go"},
+				},
 			},
 		},
 		"mixed": {
 			cmdResults: []CMDResult{
-				{Stdout: "stash"},
-				{Stderr: "new tag"},
-				{Stdout: "commit"},
-				{Stdout: "status"},
+				{Stdout: stashOutput},
+				{Stdout: tagsOutput},
+				{Stdout: commitsOutput},
+				{Stdout: changesOutput},
 			},
 		},
 	}
@@ -131,6 +241,492 @@ func TestGetProjectState(t *testing.T) {
 			state, err := git.GetProjectState("proj/path")
 			require.NoError(t, err)
 			require.False(t, state.Clean())
+			if name != "mixed" {
+				require.Equal(t, test.expected, state)
+			}
+		})
+	}
+
+	t.Run("submodules", func(t *testing.T) {
+		cmd := &FakeCMD{
+			results: []CMDResult{
+				{}, {}, {}, {},
+				{Stdout: " 1111 libs/a (v1.0)\n-2222 libs/uninit\n+3333 libs/b (heads/main)\n"},
+				{}, {}, {Stdout: "4444\x00HEAD\x00Detached fix\n"}, {},
+				{Stdout: " 5555 nested\n"},
+				{}, {}, {}, {Stdout: "?? new.txt\x00"}, {},
+				{}, {}, {}, {}, {},
+			},
+		}
+		git := NewGitAPI(cmd)
+
+		state, err := git.GetProjectState("proj/path")
+		require.NoError(t, err)
+		require.False(t, state.Clean())
+		require.Equal(
+			t,
+			GitProjectState{
+				Submodules: []SubmoduleState{
+					{
+						Path: "libs/a",
+						State: GitProjectState{
+							Commits: []GitCommit{{Hash: "4444", Branch: "HEAD", Subject: "Detached fix"}},
+							Submodules: []SubmoduleState{
+								{
+									Path:  "nested",
+									State: GitProjectState{Changes: []GitFileChange{{Status: "??", Path: "new.txt"}}},
+								},
+							},
+						},
+					},
+					{Path: "libs/b"},
+				},
+			},
+			state,
+		)
+		require.Equal(
+			t,
+			[]string{"log", "--branches", "HEAD", "--not", "--remotes", "--source", "--format=%H%x00%S%x00%s"},
+			cmd.history[7]["args"],
+		)
+		require.Equal(t, "proj/path/libs/a/nested", cmd.history[10]["dir"])
+		require.Equal(t, "proj/path/libs/b", cmd.history[15]["dir"])
+	})
+
+	t.Run("malformed output", func(t *testing.T) {
+		cmd := &FakeCMD{
+			results: []CMDResult{{Stdout: "garbage"}},
+		}
+		git := NewGitAPI(cmd)
+
+		_, err := git.GetProjectState("proj/path")
+		require.Error(t, err)
+	})
+}
+
+func TestGetCurrentBranch(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		cmd := &FakeCMD{
+			results: []CMDResult{{Stdout: "main\n"}},
+		}
+		git := NewGitAPI(cmd)
+
+		branch, err := git.GetCurrentBranch("proj/path")
+		require.NoError(t, err)
+		require.Equal(t, "main", branch)
+		require.Equal(
+			t,
+			cmd.history,
+			[]map[string]any{
+				{
+					"_method": "RunCwd",
+					"dir":     "proj/path",
+					"name":    "git",
+					"args":    []string{"rev-parse", "--abbrev-ref", "HEAD"},
+				},
+			},
+		)
+	})
+	t.Run("cmd error", func(t *testing.T) {
+		cmd := &FakeCMD{
+			err: errors.New("cmd err"),
+		}
+		git := NewGitAPI(cmd)
+		_, err := git.GetCurrentBranch("proj/path")
+		require.Error(t, err)
+	})
+}
+
+func TestGetBranchStatus(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		cmd := &FakeCMD{
+			results: []CMDResult{
+				{
+					Stdout: `# branch.oid 1234
+# branch.head main
+# branch.upstream origin/main
+# branch.ab +1 -2
+1 .M N... 100644 100644 100644 1234 1234 file.go
+2 R. N... 100644 100644 100644 1234 1234 R100 new.go	old.go
+u UU N... 100644 100644 100644 100644 1234 1234 1234 conflict.go
+? untracked.go
+`,
+				},
+			},
+		}
+		git := NewGitAPI(cmd)
+
+		status, err := git.GetBranchStatus("proj/path")
+		require.NoError(t, err)
+		require.Equal(
+			t,
+			GitBranchStatus{
+				Branch:    "main",
+				Upstream:  "origin/main",
+				Ahead:     1,
+				Behind:    2,
+				Modified:  3,
+				Untracked: 1,
+			},
+			status,
+		)
+		require.Equal(
+			t,
+			cmd.history,
+			[]map[string]any{
+				{
+					"_method": "RunCwd",
+					"dir":     "proj/path",
+					"name":    "git",
+					"args":    []string{"status", "--porcelain=v2", "--branch"},
+				},
+			},
+		)
+	})
+	t.Run("no upstream", func(t *testing.T) {
+		cmd := &FakeCMD{
+			results: []CMDResult{{Stdout: "# branch.oid 1234\n# branch.head feature\n"}},
+		}
+		git := NewGitAPI(cmd)
+
+		status, err := git.GetBranchStatus("proj/path")
+		require.NoError(t, err)
+		require.Equal(t, GitBranchStatus{Branch: "feature"}, status)
+	})
+	t.Run("malformed ahead/behind", func(t *testing.T) {
+		cmd := &FakeCMD{
+			results: []CMDResult{{Stdout: "# branch.ab +x -2\n"}},
+		}
+		git := NewGitAPI(cmd)
+
+		_, err := git.GetBranchStatus("proj/path")
+		require.Error(t, err)
+	})
+}
+
+func TestGetStashCount(t *testing.T) {
+	cmd := &FakeCMD{
+		results: []CMDResult{{Stdout: "stash@{0}\x001700000000\x00WIP\nstash@{1}\x001700000000\x00WIP\n"}},
+	}
+	git := NewGitAPI(cmd)
+
+	count, err := git.GetStashCount("proj/path")
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+}
+
+func TestGetUnpushedTagCount(t *testing.T) {
+	cmd := &FakeCMD{
+		results: []CMDResult{
+			{Stdout: "To origin\n*\trefs/tags/v1:refs/tags/v1\t[new tag]\n*\trefs/tags/v2:refs/tags/v2\t[new tag]\nDone\n"},
+		},
+	}
+	git := NewGitAPI(cmd)
+
+	count, err := git.GetUnpushedTagCount("proj/path")
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+}
+
+func TestMarkMergedUpstream(t *testing.T) {
+	commits := []GitCommit{
+		{Hash: "a1", Branch: "rebased"},
+		{Hash: "b1", Branch: "squashed"},
+		{Hash: "b2", Branch: "squashed"},
+		{Hash: "c1", Branch: "unmerged"},
+	}
+	cmd := &FakeCMD{
+		results: []CMDResult{
+			{Stdout: "refs/remotes/origin/main\n"},
+			{Stdout: "- a1\n"},
+			{Stdout: "+ b1\n+ b2\n"},
+			{Stdout: "base\n"},
+			{Stdout: "p1 0000000000000000000000000000000000000000\n"},
+			{Stdout: "p2 u2\np1 u1\n"},
+			{Stdout: "+ c1\n"},
+			{Stdout: "base\n"},
+			{Stdout: "p3 0000000000000000000000000000000000000000\n"},
+			{Stdout: "p2 u2\np1 u1\n"},
+		},
+	}
+	git := NewGitAPI(cmd)
+
+	marked, err := git.MarkMergedUpstream("proj/path", commits)
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		[]GitCommit{
+			{Hash: "a1", Branch: "rebased", MergedUpstream: true},
+			{Hash: "b1", Branch: "squashed", MergedUpstream: true},
+			{Hash: "b2", Branch: "squashed", MergedUpstream: true},
+			{Hash: "c1", Branch: "unmerged"},
+		},
+		marked,
+	)
+	require.Equal(t, []string{"cherry", "refs/remotes/origin/main", "refs/heads/rebased"}, cmd.history[1]["args"])
+	require.Equal(t, []string{"merge-base", "refs/remotes/origin/main", "refs/heads/squashed"}, cmd.history[3]["args"])
+	require.Equal(
+		t,
+		[]string{"-c", `git diff --no-color "$1" "$2" | git patch-id --stable`, "sh", "base", "refs/heads/squashed"},
+		cmd.history[4]["args"],
+	)
+	require.Equal(
+		t,
+		[]string{"-c", `git log -p --no-color "$1..$2" | git patch-id --stable`, "sh", "base", "refs/remotes/origin/main"},
+		cmd.history[5]["args"],
+	)
+
+	// Dry runs detect merged commits too, so nothing may be written to the repository
+	for _, call := range cmd.history {
+		args := strings.Join(call["args"].([]string), " ")
+		for _, writing := range []string{"commit-tree", "hash-object", "write-tree", "mktree", "update-ref"} {
+			require.NotContains(t, args, writing)
+		}
+	}
+}
+
+func TestMarkMergedUpstreamSharedCommits(t *testing.T) {
+	commits := []GitCommit{
+		{Hash: "a1", Branch: "feature"},
+		{Hash: "b1", Branch: "wip"},
+	}
+	cmd := &FakeCMD{
+		results: []CMDResult{
+			{Stdout: "refs/remotes/origin/main\n"},
+			{Stdout: "- a1\n"},
+			{Stdout: "+ a1\n+ b1\n"},
+			{Stdout: "base\n"},
+			{Stdout: "p2 0000000000000000000000000000000000000000\n"},
+			{Stdout: "p1 u1\n"},
+		},
+	}
+	git := NewGitAPI(cmd)
+
+	marked, err := git.MarkMergedUpstream("proj/path", commits)
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		[]GitCommit{{Hash: "a1", Branch: "feature", MergedUpstream: true}, {Hash: "b1", Branch: "wip"}},
+		marked,
+	)
+}
+
+func TestGetWorktrees(t *testing.T) {
+	cmd := &FakeCMD{
+		results: []CMDResult{
+			{Stdout: "/wd/proj-x\n"},
+			{Stdout: `worktree /wd/proj
+HEAD 1111
+branch refs/heads/main
+
+worktree /wd/proj-x
+HEAD 2222
+branch refs/heads/feature/x
+
+worktree /tmp/gone
+HEAD 3333
+detached
+prunable gitdir file points to non-existent location
+
+`},
+		},
+	}
+	git := NewGitAPI(cmd)
+
+	worktrees, err := git.GetWorktrees("/wd/proj-x")
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		[]GitWorktree{
+			{Path: "/wd/proj", Branch: "main", Main: true},
+			{Path: "/wd/proj-x", Branch: "feature/x", Current: true},
+			{Path: "/tmp/gone", Prunable: true},
+		},
+		worktrees,
+	)
+}
+
+func TestGetWorktreeState(t *testing.T) {
+	cmd := &FakeCMD{
+		results: []CMDResult{
+			{Stdout: "abc\x00HEAD\x00detached work\n"},
+			{Stdout: " M file.go\x00"},
+			{Stdout: ""},
+		},
+	}
+	git := NewGitAPI(cmd)
+
+	state, err := git.GetWorktreeState("/wd/proj-x")
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		GitProjectState{
+			Commits: []GitCommit{{Hash: "abc", Branch: "HEAD", Subject: "detached work"}},
+			Changes: []GitFileChange{{Path: "file.go", Status: " M"}},
+		},
+		state,
+	)
+	require.Len(t, cmd.history, 3)
+	require.Equal(
+		t,
+		[]string{"log", "HEAD", "--not", "--branches", "--tags", "--remotes", "--source", "--format=%H%x00%S%x00%s"},
+		cmd.history[0]["args"],
+	)
+}
+
+func TestAddWorktree(t *testing.T) {
+	t.Run("existing branch", func(t *testing.T) {
+		cmd := &FakeCMD{}
+		git := NewGitAPI(cmd)
+
+		require.NoError(t, git.AddWorktree("/wd/proj", "/wd/proj-x", "x"))
+		require.Len(t, cmd.history, 1)
+		require.Equal(t, []string{"worktree", "add", "/wd/proj-x", "x"}, cmd.history[0]["args"])
+	})
+	t.Run("cmd error", func(t *testing.T) {
+		cmd := &FakeCMD{err: errors.New("cmd err")}
+		git := NewGitAPI(cmd)
+
+		require.Error(t, git.AddWorktree("/wd/proj", "/wd/proj-x", "x"))
+		require.Equal(t, []string{"worktree", "add", "-b", "x", "/wd/proj-x"}, cmd.history[1]["args"])
+	})
+}
+
+func TestRemoveWorktree(t *testing.T) {
+	cmd := &FakeCMD{}
+	git := NewGitAPI(cmd)
+
+	require.NoError(t, git.RemoveWorktree("/wd/proj", "/wd/proj-x"))
+	require.Equal(t, "/wd/proj", cmd.history[0]["dir"])
+	require.Equal(t, []string{"worktree", "remove", "--force", "/wd/proj-x"}, cmd.history[0]["args"])
+
+	cmd.err = errors.New("cmd err")
+	require.ErrorContains(t, git.RemoveWorktree("/wd/proj", "/wd/proj-x"), "failed to remove the worktree")
+}
+
+func TestCheckRemote(t *testing.T) {
+	cmd := &FakeCMD{}
+	git := NewGitAPI(cmd)
+
+	require.NoError(t, git.CheckRemote("git@host:proj.git"))
+	require.Equal(t, []string{"ls-remote", "git@host:proj.git", "HEAD"}, cmd.history[0]["args"])
+	require.Equal(t, []string{"GIT_TERMINAL_PROMPT=0"}, cmd.history[0]["env"])
+
+	cmd.err = errors.New("cmd err")
+	require.ErrorContains(t, git.CheckRemote("git@host:proj.git"), "failed to reach")
+}
+
+func TestGetActivity(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, ".git"), 0755))
+		fetched := time.Now().Add(-time.Hour).Truncate(time.Second)
+		fetchHead := filepath.Join(dir, ".git", "FETCH_HEAD")
+		require.NoError(t, os.WriteFile(fetchHead, []byte("x"), 0o644))
+		require.NoError(t, os.Chtimes(fetchHead, fetched, fetched))
+		cmd := &FakeCMD{results: []CMDResult{{Stdout: "1700000000\n"}, {Stdout: ".git\n"}}}
+		git := NewGitAPI(cmd)
+
+		activity, err := git.GetActivity(dir)
+		require.NoError(t, err)
+		require.Equal(t, time.Unix(1700000000, 0), activity.LastCommit)
+		require.True(t, fetched.Equal(activity.LastFetch))
+		require.Equal(t, []string{"log", "-1", "--branches", "--format=%ct"}, cmd.history[0]["args"])
+	})
+	t.Run("no commits; never fetched", func(t *testing.T) {
+		cmd := &FakeCMD{results: []CMDResult{{}, {Stdout: t.TempDir() + "\n"}}}
+		git := NewGitAPI(cmd)
+
+		activity, err := git.GetActivity("proj/path")
+		require.NoError(t, err)
+		require.Equal(t, GitActivity{}, activity)
+	})
+	t.Run("cmd error", func(t *testing.T) {
+		git := NewGitAPI(&FakeCMD{err: errors.New("cmd err")})
+
+		_, err := git.GetActivity("proj/path")
+		require.Error(t, err)
+	})
+}
+
+func TestUpdateDefaultBranch(t *testing.T) {
+	tests := map[string]struct {
+		cmdResults       []CMDResult
+		expectedUpdated  bool
+		expectedDiverged bool
+		expectedCalls    int
+	}{
+		"fast-forwarded": {
+			cmdResults: []CMDResult{
+				{Stdout: "refs/remotes/origin/main\n"},
+				{Stdout: "feature\n"},
+				{Stdout: "1111\n"},
+				{Stdout: "1111\n"},
+				{},
+				{Stdout: "2222\n"},
+			},
+			expectedUpdated: true,
+			expectedCalls:   6,
+		},
+		"diverged": {
+			cmdResults: []CMDResult{
+				{Stdout: "refs/remotes/origin/main\n"},
+				{Stdout: "feature\n"},
+				{Stdout: "1111\n"},
+				{Stdout: "0000\n"},
+				{Stdout: "2222\n"},
+			},
+			expectedDiverged: true,
+			expectedCalls:    5,
+		},
+		"ahead": {
+			cmdResults: []CMDResult{
+				{Stdout: "refs/remotes/origin/main\n"},
+				{Stdout: "feature\n"},
+				{Stdout: "1111\n"},
+				{Stdout: "2222\n"},
+				{Stdout: "2222\n"},
+			},
+			expectedCalls: 5,
+		},
+		"checked out": {
+			cmdResults: []CMDResult{
+				{Stdout: "refs/remotes/origin/main\n"},
+				{Stdout: "main\n"},
+			},
+			expectedCalls: 2,
+		},
+		"no local branch": {
+			cmdResults: []CMDResult{
+				{Stdout: "refs/remotes/origin/main\n"},
+				{Stdout: "feature\n"},
+				{},
+			},
+			expectedCalls: 3,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cmd := &FakeCMD{results: test.cmdResults}
+			git := NewGitAPI(cmd)
+
+			update, err := git.UpdateDefaultBranch("proj/path")
+			require.NoError(t, err)
+			require.Equal(
+				t,
+				GitDefaultBranchUpdate{Branch: "main", Updated: test.expectedUpdated, Diverged: test.expectedDiverged},
+				update,
+			)
+			require.Len(t, cmd.history, test.expectedCalls)
+			if test.expectedUpdated {
+				require.Equal(t, []string{"merge-base", "refs/heads/main", "refs/remotes/origin/main"}, cmd.history[3]["args"])
+				require.Equal(
+					t,
+					[]string{"fetch", ".", "refs/remotes/origin/main:refs/heads/main"},
+					cmd.history[4]["args"],
+				)
+			}
 		})
 	}
 }
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+type HookEvent string
+
+const (
+	HookPostClone HookEvent = "post_clone"
+	HookPreDone   HookEvent = "pre_done"
+	HookPostDone  HookEvent = "post_done"
+	HookPreOpen   HookEvent = "pre_open"
+)
+
+type Hooks struct {
+	PostClone []string `json:"post_clone,omitempty"`
+	PreDone   []string `json:"pre_done,omitempty"`
+	PostDone  []string `json:"post_done,omitempty"`
+	PreOpen   []string `json:"pre_open,omitempty"`
+}
+
+func (h Hooks) Merge(other Hooks) Hooks {
+	return Hooks{
+		PostClone: append(append([]string{}, h.PostClone...), other.PostClone...),
+		PreDone:   append(append([]string{}, h.PreDone...), other.PreDone...),
+		PostDone:  append(append([]string{}, h.PostDone...), other.PostDone...),
+		PreOpen:   append(append([]string{}, h.PreOpen...), other.PreOpen...),
+	}
+}
+
+func (h Hooks) For(event HookEvent) []string {
+	switch event {
+	case HookPostClone:
+		return h.PostClone
+	case HookPreDone:
+		return h.PreDone
+	case HookPostDone:
+		return h.PostDone
+	case HookPreOpen:
+		return h.PreOpen
+	}
+	return nil
+}
+
+func (wd WorkingDir) runHooks(event HookEvent, project, projectPath string) error {
+	source := wd.cache.Get(project).Source
+	hooks := wd.config.HooksFor(project, source).For(event)
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	dir := projectPath
+	if event == HookPostDone {
+		dir = wd.directory
+	}
+	env := []string{
+		fmt.Sprintf("GW_HOOK=%s", event),
+		fmt.Sprintf("GW_PROJECT=%s", project),
+		fmt.Sprintf("GW_PATH=%s", projectPath),
+		fmt.Sprintf("GW_SOURCE=%s", source),
+		fmt.Sprintf("GW_DIR=%s", wd.directory),
+	}
+
+	for _, hook := range hooks {
+		log.Printf("running %s hook \"%s\" for \"%s\"", event, hook, project)
+		result, err := wd.cmd.RunCwdEnv(dir, env, "sh", []string{"-c", hook})
+		if output := strings.TrimSpace(result.Stdout); output != "" {
+			log.Printf("%s hook \"%s\" output:\n%s", event, hook, output)
+		}
+		if err != nil {
+			return fmt.Errorf("%s hook \"%s\" failed for \"%s\": %s", event, hook, project, err)
+		}
+	}
+	return nil
+}
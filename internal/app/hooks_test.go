@@ -0,0 +1,162 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func hookCalls(cmd *FakeCMD) []map[string]any {
+	calls := []map[string]any{}
+	for _, call := range cmd.history {
+		if call["_method"] == "RunCwdEnv" {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func TestHooksFor(t *testing.T) {
+	config := NewDefaultConfig()
+	config.Hooks = Hooks{PostClone: []string{"global"}}
+	config.SourceSettings = map[string]SourceSettings{
+		"s": {Hooks: Hooks{PostClone: []string{"source"}, PreOpen: []string{"open"}}},
+	}
+	config.ProjectSettings = map[string]ProjectSettings{
+		"proj": {Hooks: Hooks{PostClone: []string{"project"}}},
+	}
+
+	hooks := config.HooksFor("proj", "s")
+	require.Equal(t, []string{"global", "source", "project"}, hooks.For(HookPostClone))
+	require.Equal(t, []string{"open"}, hooks.For(HookPreOpen))
+	require.Empty(t, hooks.For(HookPreDone))
+
+	require.Equal(t, []string{"global"}, config.HooksFor("other", "other").For(HookPostClone))
+	require.Equal(t, []string{"global"}, config.Hooks.PostClone, "merge must not modify the original")
+}
+
+func TestHooks(t *testing.T) {
+	config := NewDefaultConfig()
+	config.Hooks = Hooks{
+		PostClone: []string{"pre-commit install"},
+		PreDone:   []string{"check"},
+		PostDone:  []string{"unregister"},
+		PreOpen:   []string{"register"},
+	}
+
+	t.Run("go; post clone and pre open", func(t *testing.T) {
+		fs := NewFakeFS()
+		git := NewFakeGit(fs).WithSources([]string{"s/proj"})
+		cmd := &FakeCMD{}
+		wd := buildWorkingDir(wdComponents{
+			fs:     fs,
+			git:    git,
+			cmd:    cmd,
+			config: &config,
+		})
+
+		err := wd.Go([]string{"proj"}, []string{"s"}, "vi", GoOpts{Open: true})
+		require.NoError(t, err)
+		env := []string{
+			"GW_PROJECT=proj",
+			"GW_PATH=/dwd/proj",
+			"GW_SOURCE=s",
+			"GW_DIR=/dwd",
+		}
+		require.Equal(
+			t,
+			[]map[string]any{
+				{
+					"_method": "RunCwdEnv",
+					"dir":     "/dwd/proj",
+					"env":     append([]string{"GW_HOOK=post_clone"}, env...),
+					"name":    "sh",
+					"args":    []string{"-c", "pre-commit install"},
+				},
+				{
+					"_method": "RunCwdEnv",
+					"dir":     "/dwd/proj",
+					"env":     append([]string{"GW_HOOK=pre_open"}, env...),
+					"name":    "sh",
+					"args":    []string{"-c", "register"},
+				},
+			},
+			hookCalls(cmd),
+		)
+	})
+	t.Run("done; pre and post done", func(t *testing.T) {
+		fs := NewFakeFS().WithRepos(
+			map[string]*FakeRepo{"/dwd/proj": {path: "/dwd/proj"}},
+		)
+		cmd := &FakeCMD{}
+		wd := buildWorkingDir(wdComponents{
+			fs:     fs,
+			git:    NewFakeGit(fs),
+			cmd:    cmd,
+			config: &config,
+		})
+
+		results, err := wd.Done([]string{"proj"}, DoneOpts{})
+		require.NoError(t, err)
+		require.Equal(t, DoneRemoved, results[0].Outcome)
+		calls := hookCalls(cmd)
+		require.Len(t, calls, 2)
+		require.Equal(t, []string{"-c", "check"}, calls[0]["args"])
+		require.Equal(t, "/dwd/proj", calls[0]["dir"])
+		require.Equal(t, []string{"-c", "unregister"}, calls[1]["args"])
+		require.Equal(t, "/dwd", calls[1]["dir"])
+	})
+	t.Run("done; vetoed", func(t *testing.T) {
+		fs := NewFakeFS().WithRepos(
+			map[string]*FakeRepo{"/dwd/proj": {path: "/dwd/proj"}},
+		)
+		cmd := &FakeCMD{err: errors.New("exit status 1")}
+		wd := buildWorkingDir(wdComponents{
+			fs:     fs,
+			git:    NewFakeGit(fs),
+			cmd:    cmd,
+			config: &config,
+		})
+
+		results, err := wd.Done([]string{"proj"}, DoneOpts{})
+		require.Error(t, err)
+		require.Equal(t, DoneVetoed, results[0].Outcome)
+		require.Contains(t, results[0].Reason, "check")
+		require.Len(t, fs.repos, 1)
+		require.Len(t, hookCalls(cmd), 1)
+	})
+	t.Run("done; veto ignored as forced", func(t *testing.T) {
+		fs := NewFakeFS().WithRepos(
+			map[string]*FakeRepo{"/dwd/proj": {path: "/dwd/proj"}},
+		)
+		cmd := &FakeCMD{err: errors.New("exit status 1")}
+		wd := buildWorkingDir(wdComponents{
+			fs:     fs,
+			git:    NewFakeGit(fs),
+			cmd:    cmd,
+			config: &config,
+		})
+
+		results, err := wd.Done([]string{"proj"}, DoneOpts{Force: true})
+		require.NoError(t, err)
+		require.Equal(t, DoneRemoved, results[0].Outcome)
+		require.Empty(t, fs.repos)
+	})
+	t.Run("done; dry run; no hooks", func(t *testing.T) {
+		fs := NewFakeFS().WithRepos(
+			map[string]*FakeRepo{"/dwd/proj": {path: "/dwd/proj"}},
+		)
+		cmd := &FakeCMD{}
+		wd := buildWorkingDir(wdComponents{
+			fs:     fs,
+			git:    NewFakeGit(fs),
+			cmd:    cmd,
+			config: &config,
+		})
+
+		_, err := wd.Done([]string{"proj"}, DoneOpts{DryRun: true})
+		require.NoError(t, err)
+		require.Empty(t, hookCalls(cmd))
+	})
+}
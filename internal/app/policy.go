@@ -0,0 +1,115 @@
+package app
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+type DonePolicy struct {
+	IgnoreUntracked            []string `json:"ignore_untracked,omitempty"`
+	IgnoreStashesOlderThanDays int      `json:"ignore_stashes_older_than_days,omitempty"`
+	IgnoreTags                 bool     `json:"ignore_tags,omitempty"`
+	DetectMerged               bool     `json:"detect_merged,omitempty"`
+}
+
+type IgnoredItem struct {
+	Item   string `json:"item"`
+	Reason string `json:"reason"`
+}
+
+func (p DonePolicy) Merge(other DonePolicy) DonePolicy {
+	merged := DonePolicy{
+		IgnoreUntracked:            append(append([]string{}, p.IgnoreUntracked...), other.IgnoreUntracked...),
+		IgnoreStashesOlderThanDays: p.IgnoreStashesOlderThanDays,
+		IgnoreTags:                 p.IgnoreTags || other.IgnoreTags,
+		DetectMerged:               p.DetectMerged || other.DetectMerged,
+	}
+	if other.IgnoreStashesOlderThanDays > 0 {
+		merged.IgnoreStashesOlderThanDays = other.IgnoreStashesOlderThanDays
+	}
+	return merged
+}
+
+// Apply drops the state items the policy allows to leave behind and reports why each of them was dropped.
+func (p DonePolicy) Apply(state GitProjectState) (GitProjectState, []IgnoredItem) {
+	var ignored []IgnoredItem
+	filtered := GitProjectState{}
+
+	for _, stash := range state.Stashes {
+		if p.IgnoreStashesOlderThanDays > 0 && stash.Age() > time.Duration(p.IgnoreStashesOlderThanDays)*24*time.Hour {
+			ignored = append(ignored, IgnoredItem{
+				Item:   fmt.Sprintf("stash %s: %s", stash.Ref, stash.Message),
+				Reason: fmt.Sprintf("older than %d days", p.IgnoreStashesOlderThanDays),
+			})
+			continue
+		}
+		filtered.Stashes = append(filtered.Stashes, stash)
+	}
+
+	for _, tag := range state.Tags {
+		if p.IgnoreTags {
+			ignored = append(ignored, IgnoredItem{
+				Item:   fmt.Sprintf("tag %s", tag),
+				Reason: "unpushed tags are ignored",
+			})
+			continue
+		}
+		filtered.Tags = append(filtered.Tags, tag)
+	}
+
+	for _, commit := range state.Commits {
+		if commit.MergedUpstream {
+			ignored = append(ignored, IgnoredItem{
+				Item:   fmt.Sprintf("commit %.7s (%s) %s", commit.Hash, commit.Branch, commit.Subject),
+				Reason: "merged upstream",
+			})
+			continue
+		}
+		filtered.Commits = append(filtered.Commits, commit)
+	}
+
+	for _, change := range state.Changes {
+		if change.Untracked() {
+			if pattern, ok := p.matchUntracked(change.Path); ok {
+				ignored = append(ignored, IgnoredItem{
+					Item:   fmt.Sprintf("untracked %s", change.Path),
+					Reason: fmt.Sprintf("matches \"%s\"", pattern),
+				})
+				continue
+			}
+		}
+		filtered.Changes = append(filtered.Changes, change)
+	}
+
+	for _, submodule := range state.Submodules {
+		subFiltered, subIgnored := p.Apply(submodule.State)
+		for _, item := range subIgnored {
+			item.Item = fmt.Sprintf("%s: %s", submodule.Path, item.Item)
+			ignored = append(ignored, item)
+		}
+		if !subFiltered.Clean() {
+			filtered.Submodules = append(filtered.Submodules, SubmoduleState{Path: submodule.Path, State: subFiltered})
+		}
+	}
+
+	return filtered, ignored
+}
+
+// matchUntracked matches patterns with a slash against the whole path and the others against the base name,
+// like .gitignore does. Untracked directories are reported by git with a trailing slash.
+func (p DonePolicy) matchUntracked(filePath string) (string, bool) {
+	filePath = strings.TrimSuffix(filePath, "/")
+	for _, pattern := range p.IgnoreUntracked {
+		trimmed := strings.TrimSuffix(pattern, "/")
+		name := filePath
+		if !strings.Contains(trimmed, "/") {
+			name = path.Base(filePath)
+		}
+		if matched, _ := path.Match(trimmed, name); matched {
+			return pattern, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDonePolicyApply(t *testing.T) {
+	oldStash := GitStash{Ref: "stash@{1}", Message: "old", Time: time.Now().Add(-40 * 24 * time.Hour)}
+	newStash := GitStash{Ref: "stash@{0}", Message: "new", Time: time.Now().Add(-time.Hour)}
+	state := GitProjectState{
+		Stashes: []GitStash{newStash, oldStash},
+		Tags:    []string{"v1"},
+		Commits: []GitCommit{{Hash: "abc", Branch: "main", Subject: "wip"}},
+		Changes: []GitFileChange{
+			{Status: "??", Path: "notes.swp"},
+			{Status: "??", Path: "src/.idea/"},
+			{Status: "??", Path: "build/out.txt"},
+			{Status: "??", Path: "main.go"},
+			{Status: " M", Path: "edited.swp"},
+		},
+	}
+
+	tests := map[string]struct {
+		policy          DonePolicy
+		expectedState   GitProjectState
+		expectedIgnored []IgnoredItem
+	}{
+		"empty policy": {
+			policy:        DonePolicy{},
+			expectedState: state,
+		},
+		"everything ignorable": {
+			policy: DonePolicy{
+				IgnoreUntracked:            []string{"*.swp", ".idea/", "build/*"},
+				IgnoreStashesOlderThanDays: 30,
+				IgnoreTags:                 true,
+			},
+			expectedState: GitProjectState{
+				Stashes: []GitStash{newStash},
+				Commits: state.Commits,
+				Changes: []GitFileChange{
+					{Status: "??", Path: "main.go"},
+					{Status: " M", Path: "edited.swp"},
+				},
+			},
+			expectedIgnored: []IgnoredItem{
+				{Item: "stash stash@{1}: old", Reason: "older than 30 days"},
+				{Item: "tag v1", Reason: "unpushed tags are ignored"},
+				{Item: "untracked notes.swp", Reason: "matches \"*.swp\""},
+				{Item: "untracked src/.idea/", Reason: "matches \".idea/\""},
+				{Item: "untracked build/out.txt", Reason: "matches \"build/*\""},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			filtered, ignored := test.policy.Apply(state)
+			require.Equal(t, test.expectedState, filtered)
+			require.Equal(t, test.expectedIgnored, ignored)
+		})
+	}
+}
+
+func TestDonePolicyApplySubmodules(t *testing.T) {
+	state := GitProjectState{
+		Submodules: []SubmoduleState{
+			{Path: "ignored", State: GitProjectState{Changes: []GitFileChange{{Status: "??", Path: "a.swp"}}}},
+			{Path: "dirty", State: GitProjectState{Tags: []string{"v1"}}},
+		},
+	}
+
+	filtered, ignored := DonePolicy{IgnoreUntracked: []string{"*.swp"}}.Apply(state)
+	require.Equal(
+		t,
+		GitProjectState{Submodules: []SubmoduleState{{Path: "dirty", State: GitProjectState{Tags: []string{"v1"}}}}},
+		filtered,
+	)
+	require.Equal(t, []IgnoredItem{{Item: "ignored: untracked a.swp", Reason: "matches \"*.swp\""}}, ignored)
+}
+
+func TestDonePolicyFor(t *testing.T) {
+	config := Config{
+		Done: DonePolicy{IgnoreUntracked: []string{"*.swp"}, IgnoreStashesOlderThanDays: 30},
+		ProjectSettings: map[string]ProjectSettings{
+			"proj": {Done: DonePolicy{IgnoreUntracked: []string{"*.log"}, IgnoreStashesOlderThanDays: 7, IgnoreTags: true}},
+		},
+	}
+
+	require.Equal(
+		t,
+		DonePolicy{IgnoreUntracked: []string{"*.swp", "*.log"}, IgnoreStashesOlderThanDays: 7, IgnoreTags: true},
+		config.DonePolicyFor("proj"),
+	)
+	require.Equal(
+		t,
+		DonePolicy{IgnoreUntracked: []string{"*.swp"}, IgnoreStashesOlderThanDays: 30},
+		config.DonePolicyFor("other"),
+	)
+}
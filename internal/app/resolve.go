@@ -0,0 +1,170 @@
+package app
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxSuggestions = 3
+
+// ChooseFunc asks which of the matches was meant by the project. An empty choice keeps the project as it is
+type ChooseFunc func(project string, matches []string) (string, error)
+
+// FuzzyMatch returns at most 3 candidates close to the query, the closest first
+func FuzzyMatch(query string, candidates []string) []string {
+	type match struct {
+		name     string
+		distance int
+	}
+
+	query = strings.ToLower(query)
+	length := utf8.RuneCountInString(query)
+	maxDistance := max(1, length/3)
+
+	matches := []match{}
+	for _, candidate := range candidates {
+		lower := strings.ToLower(candidate)
+		distance := editDistance(query, lower)
+		switch {
+		case distance <= maxDistance:
+		case length >= 3 && strings.Contains(lower, query):
+		case length >= 3 && utf8.RuneCountInString(lower) <= 2*length && isSubsequence(query, lower):
+		default:
+			continue
+		}
+		matches = append(matches, match{name: candidate, distance: distance})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	names := []string{}
+	for _, match := range matches {
+		if len(names) == maxSuggestions {
+			break
+		}
+		names = append(names, match.name)
+	}
+	return names
+}
+
+// resolveGoProject returns the project as it is if it is known or given with the owner.
+// Otherwise, it returns the chosen close match or the close matches to suggest
+func (wd WorkingDir) resolveGoProject(project string, choose ChooseFunc) (string, []string, error) {
+	if _, ok := wd.config.Aliases[project]; ok {
+		return project, nil, nil
+	}
+	if ParseProjectRef(project).Name != project {
+		return project, nil, nil
+	}
+	cached := wd.cache.List()
+	if _, ok := cached[project]; ok {
+		return project, nil, nil
+	}
+	if exists, _ := wd.fs.Exists(wd.projectPath(project)); exists {
+		return project, nil, nil
+	}
+
+	candidates := wd.KnownProjects()
+	projects, err := wd.Projects()
+	if err != nil {
+		log.Printf("failed to list the projects: %s", err)
+	}
+	for _, name := range projects {
+		if _, ok := cached[name]; !ok {
+			candidates = append(candidates, name)
+		}
+	}
+	return resolveName(project, candidates, choose)
+}
+
+// resolveDoneProject returns the name of the project in the working directory.
+// An empty name is returned with the close matches to suggest if there is no such project
+func (wd WorkingDir) resolveDoneProject(project string, choose ChooseFunc) (string, []string, error) {
+	name := wd.projectName(project)
+	if exists, err := wd.fs.Exists(wd.projectPath(name)); exists || err != nil {
+		return name, nil, nil
+	}
+
+	projects, err := wd.Projects()
+	if err != nil {
+		return "", nil, err
+	}
+	chosen, matches, err := resolveName(name, projects, choose)
+	if err != nil || chosen != name {
+		return chosen, matches, err
+	}
+	return "", matches, nil
+}
+
+func resolveName(project string, candidates []string, choose ChooseFunc) (string, []string, error) {
+	matches := FuzzyMatch(project, candidates)
+	if len(matches) == 0 || choose == nil {
+		return project, matches, nil
+	}
+	chosen, err := choose(project, matches)
+	if err != nil {
+		return "", nil, err
+	}
+	if chosen == "" {
+		return project, matches, nil
+	}
+	return chosen, nil, nil
+}
+
+func didYouMean(matches []string) string {
+	if len(matches) == 0 {
+		return ""
+	}
+	quoted := make([]string, len(matches))
+	for i, match := range matches {
+		quoted[i] = fmt.Sprintf("\"%s\"", match)
+	}
+	last := len(quoted) - 1
+	if last == 0 {
+		return fmt.Sprintf(". Did you mean %s?", quoted[0])
+	}
+	return fmt.Sprintf(". Did you mean %s or %s?", strings.Join(quoted[:last], ", "), quoted[last])
+}
+
+// editDistance counts insertions, deletions, substitutions and transpositions of adjacent characters
+func editDistance(a, b string) int {
+	source, target := []rune(a), []rune(b)
+	distances := make([][]int, len(source)+1)
+	for i := range distances {
+		distances[i] = make([]int, len(target)+1)
+		distances[i][0] = i
+	}
+	for j := range distances[0] {
+		distances[0][j] = j
+	}
+	for i := 1; i <= len(source); i++ {
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+			distances[i][j] = min(distances[i-1][j]+1, distances[i][j-1]+1, distances[i-1][j-1]+cost)
+			if i > 1 && j > 1 && source[i-1] == target[j-2] && source[i-2] == target[j-1] {
+				distances[i][j] = min(distances[i][j], distances[i-2][j-2]+1)
+			}
+		}
+	}
+	return distances[len(source)][len(target)]
+}
+
+func isSubsequence(query, candidate string) bool {
+	rest := []rune(query)
+	for _, r := range candidate {
+		if len(rest) > 0 && rest[0] == r {
+			rest = rest[1:]
+		}
+	}
+	return len(rest) == 0
+}
@@ -0,0 +1,179 @@
+package app
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	candidates := []string{"flask", "flake8", "django", "frontend-web", "fastapi", "web"}
+	tests := map[string]struct {
+		query    string
+		expected []string
+	}{
+		"typo":          {query: "flsk", expected: []string{"flask"}},
+		"case":          {query: "Django", expected: []string{"django"}},
+		"transposition": {query: "djnago", expected: []string{"django"}},
+		"short swap":    {query: "flaks", expected: []string{"flask"}},
+		"substring":     {query: "web", expected: []string{"web", "frontend-web"}},
+		"subsequence":   {query: "fapi", expected: []string{"fastapi"}},
+		"closest first": {query: "flak", expected: []string{"flask", "flake8"}},
+		"no match":      {query: "requests", expected: []string{}},
+		"too short":     {query: "fl", expected: []string{}},
+		"too far":       {query: "fsx", expected: []string{}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.expected, FuzzyMatch(test.query, candidates))
+		})
+	}
+
+	require.Empty(t, FuzzyMatch("flask", nil))
+	require.Len(t, FuzzyMatch("proj", []string{"proj1", "proj2", "proj3", "proj4"}), maxSuggestions)
+}
+
+func TestDidYouMean(t *testing.T) {
+	require.Equal(t, "", didYouMean(nil))
+	require.Equal(t, ". Did you mean \"flask\"?", didYouMean([]string{"flask"}))
+	require.Equal(
+		t,
+		". Did you mean \"flask\", \"flake8\" or \"flash\"?",
+		didYouMean([]string{"flask", "flake8", "flash"}),
+	)
+}
+
+func TestResolveGo(t *testing.T) {
+	newWD := func() (WorkingDir, *FakeFS, *FakeGit) {
+		fs := NewFakeFS().WithRepos(map[string]*FakeRepo{"/dwd/flask": {path: "/dwd/flask"}})
+		git := NewFakeGit(fs).WithSources([]string{"s/flsk"})
+		config := NewDefaultConfig()
+		config.Aliases = map[string]Alias{"backend": {Source: "s"}}
+		cache := NewFakeCache(map[string]ProjectInfo{"requests": {Source: "s"}})
+		return buildWorkingDir(wdComponents{fs: fs, git: git, config: &config, cache: cache}), fs, git
+	}
+
+	t.Run("known names are kept", func(t *testing.T) {
+		wd, _, _ := newWD()
+		for _, project := range []string{"flask", "backend", "requests", "owner/flsk"} {
+			resolved, suggestions, err := wd.resolveGoProject(project, func(string, []string) (string, error) {
+				return "", fmt.Errorf("must not be asked")
+			})
+			require.NoError(t, err)
+			require.Equal(t, project, resolved)
+			require.Empty(t, suggestions)
+		}
+	})
+	t.Run("matched against directories, cache and aliases", func(t *testing.T) {
+		wd, _, _ := newWD()
+		for project, expected := range map[string]string{"flsk": "flask", "bakend": "backend", "reqests": "requests"} {
+			resolved, suggestions, err := wd.resolveGoProject(project, nil)
+			require.NoError(t, err)
+			require.Equal(t, project, resolved)
+			require.Equal(t, []string{expected}, suggestions)
+		}
+	})
+	t.Run("chosen", func(t *testing.T) {
+		wd, _, git := newWD()
+		var asked []string
+		err := wd.Go([]string{"flsk"}, []string{"s"}, "", GoOpts{
+			Choose: func(project string, matches []string) (string, error) {
+				asked = matches
+				return matches[0], nil
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"flask"}, asked)
+		require.Empty(t, git.clones)
+	})
+	t.Run("kept as it is", func(t *testing.T) {
+		wd, fs, _ := newWD()
+		err := wd.Go([]string{"flsk"}, []string{"s"}, "", GoOpts{
+			Choose: func(string, []string) (string, error) { return "", nil },
+		})
+		require.NoError(t, err)
+		require.Contains(t, fs.repos, "/dwd/flsk")
+	})
+	t.Run("choice failed", func(t *testing.T) {
+		wd, _, _ := newWD()
+		err := wd.Go([]string{"flsk"}, []string{"s"}, "", GoOpts{
+			Choose: func(string, []string) (string, error) { return "", fmt.Errorf("interrupted") },
+		})
+		require.ErrorContains(t, err, "interrupted")
+	})
+	t.Run("suggested on a failed clone", func(t *testing.T) {
+		wd, _, _ := newWD()
+		target, err := wd.resolveTarget("flsk", []string{"x"})
+		require.NoError(t, err)
+		target.suggestions = []string{"flask"}
+
+		err = wd.clone(target, CloneOpts{})
+		require.EqualError(t, err, "failed to clone \"flsk\". Tried all configured sources. Did you mean \"flask\"?")
+	})
+}
+
+func TestResolveDone(t *testing.T) {
+	newWD := func() (WorkingDir, *FakeFS) {
+		fs := NewFakeFS().WithRepos(map[string]*FakeRepo{
+			"/dwd/flask":  {path: "/dwd/flask"},
+			"/dwd/django": {path: "/dwd/django"},
+		})
+		return buildWorkingDir(wdComponents{fs: fs, git: NewFakeGit(fs)}), fs
+	}
+
+	t.Run("missing project is suggested", func(t *testing.T) {
+		wd, fs := newWD()
+		results, err := wd.Done([]string{"flsk", "django"}, DoneOpts{})
+		require.ErrorContains(t, err, "\"flsk\" was not removed")
+		require.Equal(
+			t,
+			[]DoneResult{
+				{
+					Project: "flsk",
+					Path:    "/dwd/flsk",
+					Outcome: DoneError,
+					Reason:  "the project is not in the working directory. Did you mean \"flask\"?",
+				},
+				{Project: "django", Path: "/dwd/django", Outcome: DoneRemoved},
+			},
+			results,
+		)
+		require.Contains(t, fs.repos, "/dwd/flask")
+	})
+	t.Run("missing project without matches", func(t *testing.T) {
+		wd, _ := newWD()
+		results, err := wd.Done([]string{"requests"}, DoneOpts{})
+		require.Error(t, err)
+		require.Equal(t, "the project is not in the working directory", results[0].Reason)
+	})
+	t.Run("chosen", func(t *testing.T) {
+		wd, fs := newWD()
+		results, err := wd.Done([]string{"flsk"}, DoneOpts{
+			Choose: func(project string, matches []string) (string, error) {
+				require.Equal(t, "flsk", project)
+				return matches[0], nil
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []DoneResult{{Project: "flask", Path: "/dwd/flask", Outcome: DoneRemoved}}, results)
+		require.NotContains(t, fs.repos, "/dwd/flask")
+	})
+	t.Run("chosen twice", func(t *testing.T) {
+		wd, _ := newWD()
+		results, err := wd.Done([]string{"flsk", "flask"}, DoneOpts{
+			Choose: func(project string, matches []string) (string, error) { return matches[0], nil },
+		})
+		require.NoError(t, err)
+		require.Equal(t, []DoneResult{{Project: "flask", Path: "/dwd/flask", Outcome: DoneRemoved}}, results)
+	})
+	t.Run("skipped", func(t *testing.T) {
+		wd, fs := newWD()
+		results, err := wd.Done([]string{"flsk"}, DoneOpts{
+			Choose: func(string, []string) (string, error) { return "", nil },
+		})
+		require.Error(t, err)
+		require.Equal(t, DoneError, results[0].Outcome)
+		require.Contains(t, fs.repos, "/dwd/flask")
+	})
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ProjectRef struct {
+	Host  string
+	Owner string
+	Name  string
+}
+
+func (ref ProjectRef) String() string {
+	return strings.Join(ref.parts(), "/")
+}
+
+func (ref ProjectRef) parts() []string {
+	parts := []string{}
+	if ref.Host != "" {
+		parts = append(parts, ref.Host)
+	}
+	if ref.Owner != "" {
+		parts = append(parts, ref.Owner)
+	}
+	return append(parts, ref.Name)
+}
+
+// ParseProjectRef parses "[host/]owner/.../project". The first part is taken
+// as a host only if it looks like a domain name, e.g. "github.com/pallets/flask".
+func ParseProjectRef(value string) ProjectRef {
+	parts := strings.Split(strings.Trim(value, "/"), "/")
+	ref := ProjectRef{Name: parts[len(parts)-1]}
+	parts = parts[:len(parts)-1]
+
+	if len(parts) > 1 && strings.Contains(parts[0], ".") {
+		ref.Host = parts[0]
+		parts = parts[1:]
+	}
+	ref.Owner = strings.Join(parts, "/")
+	return ref
+}
+
+func ExpandSource(source string, ref ProjectRef) (string, error) {
+	if !strings.Contains(source, "{") {
+		tail := ref.Name
+		if ref.Owner != "" {
+			tail = ref.Owner + "/" + ref.Name
+		}
+		if strings.HasSuffix(source, "/") || strings.HasSuffix(source, ":") {
+			return source + tail, nil
+		}
+		return source + "/" + tail, nil
+	}
+
+	placeholders := map[string]string{
+		"{project}": ref.Name,
+		"{owner}":   ref.Owner,
+		"{host}":    ref.Host,
+	}
+	url := source
+	for placeholder, value := range placeholders {
+		if !strings.Contains(url, placeholder) {
+			continue
+		}
+		if value == "" {
+			return "", fmt.Errorf("source \"%s\" requires %s for \"%s\"", source, placeholder, ref)
+		}
+		url = strings.ReplaceAll(url, placeholder, value)
+	}
+
+	if strings.Contains(url, "{") {
+		return "", fmt.Errorf("source \"%s\" has unknown placeholders", source)
+	}
+	return url, nil
+}
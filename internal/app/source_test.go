@@ -0,0 +1,88 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProjectRef(t *testing.T) {
+	tests := map[string]ProjectRef{
+		"flask":                         {Name: "flask"},
+		"pallets/flask":                 {Owner: "pallets", Name: "flask"},
+		"group/sub/proj":                {Owner: "group/sub", Name: "proj"},
+		"github.com/pallets/flask":      {Host: "github.com", Owner: "pallets", Name: "flask"},
+		"gitlab.corp/group/sub/proj":    {Host: "gitlab.corp", Owner: "group/sub", Name: "proj"},
+		"my.org/flask":                  {Owner: "my.org", Name: "flask"},
+		"/pallets/flask/":               {Owner: "pallets", Name: "flask"},
+		"github.com/pallets/flask.git/": {Host: "github.com", Owner: "pallets", Name: "flask.git"},
+	}
+
+	for value, expected := range tests {
+		t.Run(value, func(t *testing.T) {
+			require.Equal(t, expected, ParseProjectRef(value))
+		})
+	}
+}
+
+func TestExpandSource(t *testing.T) {
+	tests := map[string]struct {
+		source   string
+		ref      ProjectRef
+		expected string
+		err      bool
+	}{
+		"prefix": {
+			source:   "https://github.com/pallets",
+			ref:      ProjectRef{Name: "flask"},
+			expected: "https://github.com/pallets/flask",
+		},
+		"prefix; trailing slash": {
+			source:   "https://github.com/",
+			ref:      ProjectRef{Owner: "pallets", Name: "flask"},
+			expected: "https://github.com/pallets/flask",
+		},
+		"prefix; scp-like": {
+			source:   "git@gitlab.corp:group/sub",
+			ref:      ProjectRef{Name: "proj"},
+			expected: "git@gitlab.corp:group/sub/proj",
+		},
+		"prefix; scp-like host only": {
+			source:   "git@github.com:",
+			ref:      ProjectRef{Owner: "pallets", Name: "flask"},
+			expected: "git@github.com:pallets/flask",
+		},
+		"template": {
+			source:   "git@gitlab.corp:{owner}/{project}.git",
+			ref:      ProjectRef{Owner: "group/sub", Name: "proj"},
+			expected: "git@gitlab.corp:group/sub/proj.git",
+		},
+		"template; host": {
+			source:   "https://{host}/{owner}/{project}",
+			ref:      ProjectRef{Host: "github.com", Owner: "pallets", Name: "flask"},
+			expected: "https://github.com/pallets/flask",
+		},
+		"template; missing owner": {
+			source: "git@gitlab.corp:{owner}/{project}.git",
+			ref:    ProjectRef{Name: "proj"},
+			err:    true,
+		},
+		"template; unknown placeholder": {
+			source: "git@gitlab.corp:{group}/{project}.git",
+			ref:    ProjectRef{Name: "proj"},
+			err:    true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			url, err := ExpandSource(test.source, test.ref)
+			if test.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, url)
+		})
+	}
+}
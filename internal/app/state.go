@@ -0,0 +1,198 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type GitProjectState struct {
+	Stashes    []GitStash       `json:"stashes,omitempty"`
+	Tags       []string         `json:"tags,omitempty"`
+	Commits    []GitCommit      `json:"commits,omitempty"`
+	Changes    []GitFileChange  `json:"changes,omitempty"`
+	Submodules []SubmoduleState `json:"submodules,omitempty"`
+}
+
+type SubmoduleState struct {
+	Path  string          `json:"path"`
+	State GitProjectState `json:"state"`
+}
+
+type GitStash struct {
+	Ref     string    `json:"ref"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+func (s GitStash) Age() time.Duration {
+	return time.Since(s.Time)
+}
+
+type GitCommit struct {
+	Hash           string `json:"hash"`
+	Branch         string `json:"branch"`
+	Subject        string `json:"subject"`
+	MergedUpstream bool   `json:"merged_upstream,omitempty"`
+}
+
+type GitFileChange struct {
+	Status   string `json:"status"`
+	Path     string `json:"path"`
+	OrigPath string `json:"orig_path,omitempty"`
+}
+
+func (c GitFileChange) Untracked() bool {
+	return c.Status == "??"
+}
+
+func (state GitProjectState) String() string {
+	sections := []string{}
+	if len(state.Stashes) > 0 {
+		lines := []string{"Stashes:"}
+		for _, stash := range state.Stashes {
+			lines = append(
+				lines,
+				fmt.Sprintf("  %s: %s (%s)", stash.Ref, stash.Message, stash.Time.Format(time.DateTime)),
+			)
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+	if len(state.Tags) > 0 {
+		lines := []string{"Tags:"}
+		for _, tag := range state.Tags {
+			lines = append(lines, fmt.Sprintf("  %s", tag))
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+	if len(state.Commits) > 0 {
+		lines := []string{"Commits:"}
+		for _, commit := range state.Commits {
+			line := fmt.Sprintf("  %.7s (%s) %s", commit.Hash, commit.Branch, commit.Subject)
+			if commit.MergedUpstream {
+				line += " [merged upstream]"
+			}
+			lines = append(lines, line)
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+	if len(state.Changes) > 0 {
+		lines := []string{"Changes:"}
+		for _, change := range state.Changes {
+			line := fmt.Sprintf("  %s %s", change.Status, change.Path)
+			if change.OrigPath != "" {
+				line += fmt.Sprintf(" <- %s", change.OrigPath)
+			}
+			lines = append(lines, line)
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+	if len(state.Submodules) > 0 {
+		lines := []string{"Submodules:"}
+		for _, submodule := range state.Submodules {
+			if submodule.State.Clean() {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("  %s:", submodule.Path))
+			for _, line := range strings.Split(submodule.State.String(), "\n") {
+				lines = append(lines, "    "+line)
+			}
+		}
+		if len(lines) > 1 {
+			sections = append(sections, strings.Join(lines, "\n"))
+		}
+	}
+	return strings.Join(sections, "\n")
+}
+
+func (state GitProjectState) Clean() bool {
+	return len(state.Stashes) == 0 &&
+		len(state.Tags) == 0 &&
+		!slices.ContainsFunc(state.Commits, func(commit GitCommit) bool { return !commit.MergedUpstream }) &&
+		len(state.Changes) == 0 &&
+		!slices.ContainsFunc(state.Submodules, func(submodule SubmoduleState) bool { return !submodule.State.Clean() })
+}
+
+func parseStashes(output string) ([]GitStash, error) {
+	var stashes []GitStash
+	for _, line := range nonEmptyLines(output) {
+		fields := strings.SplitN(line, "\x00", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected stash line \"%s\"", line)
+		}
+		timestamp, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected stash time \"%s\": %s", fields[1], err)
+		}
+		stashes = append(
+			stashes,
+			GitStash{Ref: fields[0], Message: fields[2], Time: time.Unix(timestamp, 0)},
+		)
+	}
+	return stashes, nil
+}
+
+func parseNewTags(output string) []string {
+	var tags []string
+	for _, line := range nonEmptyLines(output) {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 || fields[2] != "[new tag]" {
+			continue
+		}
+		src, _, _ := strings.Cut(fields[1], ":")
+		tags = append(tags, strings.TrimPrefix(src, "refs/tags/"))
+	}
+	return tags
+}
+
+func parseCommits(output string) ([]GitCommit, error) {
+	var commits []GitCommit
+	for _, line := range nonEmptyLines(output) {
+		fields := strings.SplitN(line, "\x00", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected commit line \"%s\"", line)
+		}
+		commits = append(
+			commits,
+			GitCommit{Hash: fields[0], Branch: strings.TrimPrefix(fields[1], "refs/heads/"), Subject: fields[2]},
+		)
+	}
+	return commits, nil
+}
+
+func parseChanges(output string) ([]GitFileChange, error) {
+	var changes []GitFileChange
+	entries := strings.Split(strings.TrimSuffix(output, "\x00"), "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if entry == "" {
+			continue
+		}
+		if len(entry) < 4 || entry[2] != ' ' {
+			return nil, fmt.Errorf("unexpected status entry \"%s\"", entry)
+		}
+
+		change := GitFileChange{Status: entry[:2], Path: entry[3:]}
+		if change.Status[0] == 'R' || change.Status[0] == 'C' {
+			if i+1 >= len(entries) {
+				return nil, fmt.Errorf("missing the original path of \"%s\"", change.Path)
+			}
+			i++
+			change.OrigPath = entries[i]
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func nonEmptyLines(output string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
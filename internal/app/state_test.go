@@ -0,0 +1,109 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitProjectStateString(t *testing.T) {
+	stashTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)
+	state := GitProjectState{
+		Stashes: []GitStash{{Ref: "stash@{0}", Message: "On main: wip", Time: stashTime}},
+		Tags:    []string{"v1"},
+		Commits: []GitCommit{{Hash: "1234567890", Branch: "feature", Subject: "Add feature"}},
+		Changes: []GitFileChange{
+			{Status: " M", Path: "file.go"},
+			{Status: "R ", Path: "new.go", OrigPath: "old.go"},
+		},
+	}
+
+	require.Equal(
+		t,
+		`Stashes:
+  stash@{0}: On main: wip (2024-01-02 03:04:05)
+Tags:
+  v1
+Commits:
+  1234567 (feature) Add feature
+Changes:
+   M file.go
+  R  new.go <- old.go`,
+		state.String(),
+	)
+	require.False(t, state.Clean())
+	require.Equal(t, "Tags:\n  v1", GitProjectState{Tags: []string{"v1"}}.String())
+	require.Empty(t, GitProjectState{}.String())
+	require.True(t, GitProjectState{}.Clean())
+	require.True(t, GitProjectState{Commits: []GitCommit{{Hash: "1", MergedUpstream: true}}}.Clean())
+}
+
+func TestGitProjectStateSubmodules(t *testing.T) {
+	state := GitProjectState{
+		Submodules: []SubmoduleState{
+			{Path: "clean"},
+			{
+				Path: "libs/a",
+				State: GitProjectState{
+					Submodules: []SubmoduleState{
+						{Path: "nested", State: GitProjectState{Tags: []string{"v1"}}},
+					},
+				},
+			},
+		},
+	}
+
+	require.False(t, state.Clean())
+	require.Equal(
+		t,
+		`Submodules:
+  libs/a:
+    Submodules:
+      nested:
+        Tags:
+          v1`,
+		state.String(),
+	)
+	require.True(t, GitProjectState{Submodules: []SubmoduleState{{Path: "clean"}}}.Clean())
+}
+
+func TestParseChanges(t *testing.T) {
+	tests := map[string]struct {
+		output   string
+		expected []GitFileChange
+		err      bool
+	}{
+		"empty": {
+			output: "",
+		},
+		"copied": {
+			output:   "C  copy.go\x00orig.go\x00",
+			expected: []GitFileChange{{Status: "C ", Path: "copy.go", OrigPath: "orig.go"}},
+		},
+		"path with spaces": {
+			output:   "?? my file.go\x00",
+			expected: []GitFileChange{{Status: "??", Path: "my file.go"}},
+		},
+		"renamed; missing original path": {
+			output: "R  new.go",
+			err:    true,
+		},
+		"malformed": {
+			output: "x\x00",
+			err:    true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			changes, err := parseChanges(test.output)
+			if test.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, changes)
+		})
+	}
+}
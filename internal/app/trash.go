@@ -0,0 +1,183 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var TrashDir = path.Join(getCacheDir(), "trash")
+
+type ITrash interface {
+	Put(path string, entry TrashEntry) (TrashEntry, error)
+	List() ([]TrashEntry, error)
+	Find(project string) (TrashEntry, error)
+	Forget(entry TrashEntry) error
+	Path(entry TrashEntry) string
+	Purge(olderThan time.Duration) ([]TrashEntry, error)
+}
+
+type TrashEntry struct {
+	ID           string    `json:"id"`
+	Project      string    `json:"project"`
+	OriginalPath string    `json:"original_path"`
+	Source       string    `json:"source"`
+	RemovedAt    time.Time `json:"removed_at"`
+}
+
+type Trash struct {
+	dir string
+	fs  FileSystem
+}
+
+func (t Trash) Put(path string, entry TrashEntry) (TrashEntry, error) {
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return entry, fmt.Errorf("failed to create the trash directory \"%s\": %s", t.dir, err)
+	}
+
+	// The entry is written first, so that a project is never in the trash without it
+	entry.ID = fmt.Sprintf("%s-%d", entry.Project, entry.RemovedAt.UnixNano())
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return entry, fmt.Errorf("failed to marshal the trash entry of \"%s\": %s", entry.Project, err)
+	}
+	if err = os.WriteFile(t.metaPath(entry), data, 0o644); err != nil {
+		return entry, fmt.Errorf("failed to write the trash entry of \"%s\": %s", entry.Project, err)
+	}
+
+	if err = t.fs.Move(path, t.Path(entry)); err != nil {
+		if removeErr := os.Remove(t.metaPath(entry)); removeErr != nil {
+			log.Printf("failed to remove the trash entry of \"%s\": %s", entry.Project, removeErr)
+		}
+		return entry, err
+	}
+	return entry, nil
+}
+
+func (t Trash) List() ([]TrashEntry, error) {
+	files, err := filepath.Glob(filepath.Join(t.dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the trash at \"%s\": %s", t.dir, err)
+	}
+
+	entries := []TrashEntry{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the trash entry \"%s\": %s", file, err)
+		}
+
+		var entry TrashEntry
+		if err = json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal the trash entry \"%s\": %s", file, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].RemovedAt.Before(entries[j].RemovedAt)
+	})
+	return entries, nil
+}
+
+func (t Trash) Find(project string) (TrashEntry, error) {
+	entries, err := t.List()
+	if err != nil {
+		return TrashEntry{}, err
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Project == project {
+			return entries[i], nil
+		}
+	}
+	return TrashEntry{}, fmt.Errorf("\"%s\" is not in the trash", project)
+}
+
+func (t Trash) Forget(entry TrashEntry) error {
+	if err := os.Remove(t.metaPath(entry)); err != nil {
+		return fmt.Errorf("failed to remove the trash entry of \"%s\": %s", entry.Project, err)
+	}
+	return nil
+}
+
+func (t Trash) Purge(olderThan time.Duration) ([]TrashEntry, error) {
+	entries, err := t.List()
+	if err != nil {
+		return nil, err
+	}
+
+	purged := []TrashEntry{}
+	for _, entry := range entries {
+		if time.Since(entry.RemovedAt) < olderThan {
+			continue
+		}
+		if err = t.fs.Remove(t.Path(entry)); err != nil {
+			return purged, err
+		}
+		if err = t.Forget(entry); err != nil {
+			return purged, err
+		}
+		log.Printf("purged \"%s\" from the trash", entry.ID)
+		purged = append(purged, entry)
+	}
+
+	return purged, nil
+}
+
+func (t Trash) Path(entry TrashEntry) string {
+	return filepath.Join(t.dir, entry.ID)
+}
+
+func (t Trash) metaPath(entry TrashEntry) string {
+	return filepath.Join(t.dir, entry.ID+".json")
+}
+
+func NewTrash(dir string, fs FileSystem) Trash {
+	return Trash{
+		dir: dir,
+		fs:  fs,
+	}
+}
+
+// ParseAge parses a positive age given in days, e.g. "30d", weeks, e.g. "2w", or as a Go duration, e.g. "12h".
+func ParseAge(value string) (time.Duration, error) {
+	age, err := parseAge(value)
+	if err != nil {
+		return 0, err
+	}
+	if age <= 0 {
+		return 0, fmt.Errorf("invalid age \"%s\": it must be positive", value)
+	}
+	return age, nil
+}
+
+func parseAge(value string) (time.Duration, error) {
+	units := map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	}
+	for suffix, unit := range units {
+		if !strings.HasSuffix(value, suffix) {
+			continue
+		}
+		count, err := strconv.ParseFloat(strings.TrimSuffix(value, suffix), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age \"%s\"", value)
+		}
+		return time.Duration(count * float64(unit)), nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age \"%s\"", value)
+	}
+	return duration, nil
+}
@@ -0,0 +1,153 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrash(t *testing.T) {
+	t.Run("put; list; find; forget", func(t *testing.T) {
+		root := t.TempDir()
+		project := createGitDir(t, root, "proj")
+		trash := NewTrash(filepath.Join(root, "trash"), NewOSFileSystem(&FakeCMD{}))
+
+		entry, err := trash.Put(
+			project,
+			TrashEntry{
+				Project:      "proj",
+				OriginalPath: project,
+				Source:       "s",
+				RemovedAt:    time.Now(),
+			},
+		)
+		require.NoError(t, err)
+		require.DirExists(t, filepath.Join(trash.Path(entry), ".git"))
+		require.NoDirExists(t, project)
+
+		entries, err := trash.List()
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.Equal(t, entry.ID, entries[0].ID)
+
+		found, err := trash.Find("proj")
+		require.NoError(t, err)
+		require.Equal(t, entry.ID, found.ID)
+
+		require.NoError(t, trash.Forget(found))
+		_, err = trash.Find("proj")
+		require.Error(t, err)
+	})
+	t.Run("put; entry not written; project kept", func(t *testing.T) {
+		root := t.TempDir()
+		project := createGitDir(t, root, "proj")
+		trash := NewTrash(filepath.Join(root, "trash"), NewOSFileSystem(&FakeCMD{}))
+		entry := TrashEntry{Project: "proj", RemovedAt: time.Now()}
+		entry.ID = fmt.Sprintf("proj-%d", entry.RemovedAt.UnixNano())
+		require.NoError(t, os.MkdirAll(trash.metaPath(entry), 0o755))
+
+		_, err := trash.Put(project, entry)
+		require.ErrorContains(t, err, "failed to write the trash entry")
+		require.DirExists(t, filepath.Join(project, ".git"))
+		require.NoDirExists(t, trash.Path(entry))
+	})
+	t.Run("put; move failed; entry removed", func(t *testing.T) {
+		root := t.TempDir()
+		trash := NewTrash(filepath.Join(root, "trash"), NewOSFileSystem(&FakeCMD{}))
+
+		_, err := trash.Put(filepath.Join(root, "missing"), TrashEntry{Project: "missing", RemovedAt: time.Now()})
+		require.ErrorContains(t, err, "failed to move")
+
+		entries, err := trash.List()
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+	t.Run("find; the most recent", func(t *testing.T) {
+		root := t.TempDir()
+		trash := NewTrash(filepath.Join(root, "trash"), NewOSFileSystem(&FakeCMD{}))
+		now := time.Now()
+		for _, removedAt := range []time.Time{now, now.Add(-time.Hour)} {
+			_, err := trash.Put(
+				createGitDir(t, t.TempDir(), "proj"),
+				TrashEntry{Project: "proj", RemovedAt: removedAt},
+			)
+			require.NoError(t, err)
+		}
+
+		entry, err := trash.Find("proj")
+		require.NoError(t, err)
+		require.True(t, entry.RemovedAt.Equal(now))
+	})
+	t.Run("purge", func(t *testing.T) {
+		root := t.TempDir()
+		trash := NewTrash(filepath.Join(root, "trash"), NewOSFileSystem(&FakeCMD{}))
+		old, err := trash.Put(
+			createGitDir(t, root, "old"),
+			TrashEntry{Project: "old", RemovedAt: time.Now().Add(-48 * time.Hour)},
+		)
+		require.NoError(t, err)
+		_, err = trash.Put(
+			createGitDir(t, root, "new"),
+			TrashEntry{Project: "new", RemovedAt: time.Now()},
+		)
+		require.NoError(t, err)
+
+		purged, err := trash.Purge(24 * time.Hour)
+		require.NoError(t, err)
+		require.Len(t, purged, 1)
+		require.Equal(t, "old", purged[0].Project)
+		require.NoDirExists(t, trash.Path(old))
+
+		entries, err := trash.List()
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.Equal(t, "new", entries[0].Project)
+	})
+	t.Run("list; no trash yet", func(t *testing.T) {
+		trash := NewTrash(filepath.Join(t.TempDir(), "trash"), NewOSFileSystem(&FakeCMD{}))
+		entries, err := trash.List()
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+	t.Run("list; corrupted entry", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "x.json"), []byte("{"), 0o644))
+		trash := NewTrash(dir, NewOSFileSystem(&FakeCMD{}))
+		_, err := trash.List()
+		require.Error(t, err)
+	})
+}
+
+func TestParseAge(t *testing.T) {
+	tests := map[string]struct {
+		value    string
+		expected time.Duration
+		err      bool
+	}{
+		"days":     {value: "30d", expected: 30 * 24 * time.Hour},
+		"weeks":    {value: "2w", expected: 14 * 24 * time.Hour},
+		"fraction": {value: "1.5d", expected: 36 * time.Hour},
+		"hours":    {value: "12h", expected: 12 * time.Hour},
+		"garbage":  {value: "3x", err: true},
+		"bad days": {value: "xd", err: true},
+		"zero":     {value: "0", err: true},
+		"negative": {value: "-1d", err: true},
+		"past":     {value: "-2h", err: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			age, err := ParseAge(test.value)
+			if test.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, age)
+		})
+	}
+}
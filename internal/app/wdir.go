@@ -1,80 +1,247 @@
 package app
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"maps"
 	"os"
 	"path"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
+// now is replaced in tests
+var now = time.Now
+
 type WorkingDir struct {
 	directory string
 	git       Git
 	fs        FileSystem
 	config    Config
 	cache     ICache
+	trash     ITrash
+	cmd       CMD
 }
 
 func NewWorkingDir(directory string, config Config, cache ICache) WorkingDir {
 	cmd := NewOSExec()
+	fs := NewOSFileSystem(cmd)
 	return WorkingDir{
 		directory: directory,
 		git:       NewGitAPI(cmd),
-		fs:        NewOSFileSystem(cmd),
+		fs:        fs,
 		config:    config,
 		cache:     cache,
+		trash:     NewTrash(TrashDir, fs),
+		cmd:       cmd,
 	}
 }
 
 type GoOpts struct {
-	Open bool
+	Open     bool
+	Jobs     int
+	Clone    CloneOpts
+	Worktree string
+	// Choose picks the project meant by an unknown name if set
+	Choose ChooseFunc
 }
 
 type DoneOpts struct {
-	Force bool
+	Force        bool
+	DryRun       bool
+	DetectMerged bool
+	// Stale limits the projects to the ones not used for longer than this if set
+	Stale time.Duration
+	// Choose picks the project meant by a missing name if set
+	Choose ChooseFunc
+}
+
+type DoneOutcome string
+
+const (
+	DoneRemoved       DoneOutcome = "removed"
+	DoneKeptDirty     DoneOutcome = "kept-dirty"
+	DoneKeptWorktrees DoneOutcome = "kept-worktrees"
+	DoneVetoed        DoneOutcome = "vetoed"
+	DoneWouldRemove   DoneOutcome = "would-remove"
+	DoneError         DoneOutcome = "error"
+)
+
+type DoneResult struct {
+	Project string          `json:"project"`
+	Path    string          `json:"path"`
+	Outcome DoneOutcome     `json:"outcome"`
+	Reason  string          `json:"reason,omitempty"`
+	State   GitProjectState `json:"state"`
+	Ignored []IgnoredItem   `json:"ignored,omitempty"`
+	TrashID string          `json:"trash_id,omitempty"`
+}
+
+type SyncOpts struct {
+	Jobs          int
+	DefaultBranch bool
+}
+
+type SyncOutcome string
+
+const (
+	SyncUpdated      SyncOutcome = "updated"
+	SyncUpToDate     SyncOutcome = "up-to-date"
+	SyncSkippedDirty SyncOutcome = "skipped-dirty"
+	SyncDiverged     SyncOutcome = "diverged"
+	SyncNoUpstream   SyncOutcome = "no-upstream"
+	SyncError        SyncOutcome = "error"
+)
+
+type SyncResult struct {
+	Project               string      `json:"project"`
+	Branch                string      `json:"branch,omitempty"`
+	Outcome               SyncOutcome `json:"outcome"`
+	Ahead                 int         `json:"ahead"`
+	Behind                int         `json:"behind"`
+	Reason                string      `json:"reason,omitempty"`
+	DefaultBranch         string      `json:"default_branch,omitempty"`
+	DefaultBranchUpdated  bool        `json:"default_branch_updated,omitempty"`
+	DefaultBranchDiverged bool        `json:"default_branch_diverged,omitempty"`
+}
+
+type GoPlan struct {
+	Projects []ProjectPlan
+	Editors  []string
+	OpenPath string
+}
+
+type ProjectPlan struct {
+	Project  string
+	Path     string
+	Exists   bool
+	URLs     []string
+	Worktree string
+	Err      error
+}
+
+type goTarget struct {
+	name        string
+	ref         ProjectRef
+	candidates  []cloneCandidate
+	suggestions []string
+}
+
+type cloneCandidate struct {
+	source string
+	url    string
+}
+
+type ProjectSummary struct {
+	Name   string
+	Source string
+	Branch string
+	Clean  bool
+	Err    error
+}
+
+// ProjectActivity is zero-valued for the things that never happened.
+type ProjectActivity struct {
+	LastCommit   time.Time `json:"last_commit,omitzero"`
+	LastFetch    time.Time `json:"last_fetch,omitzero"`
+	LastModified time.Time `json:"last_modified,omitzero"`
+	LastOpened   time.Time `json:"last_opened,omitzero"`
+}
+
+// Last returns the time of the latest activity.
+func (a ProjectActivity) Last() time.Time {
+	last := a.LastCommit
+	for _, t := range []time.Time{a.LastFetch, a.LastModified, a.LastOpened} {
+		if t.After(last) {
+			last = t
+		}
+	}
+	return last
+}
+
+type StaleProject struct {
+	Name     string          `json:"name"`
+	Activity ProjectActivity `json:"activity"`
+}
+
+type PruneOpts struct {
+	Jobs   int
+	DryRun bool
+}
+
+type PrunedProject struct {
+	Project string `json:"project"`
+	Reason  string `json:"reason"`
+}
+
+type RecentProject struct {
+	Name string
+	ProjectInfo
+	Present bool
+}
+
+type ProjectStatus struct {
+	Name string
+	GitBranchStatus
+	Stashes      int
+	UnpushedTags int
+	Err          error
 }
 
 func (wd WorkingDir) Go(projects, sources []string, editor string, opts GoOpts) error {
 	if len(projects) == 0 {
 		return fmt.Errorf("no projects to go specified")
 	}
-	var lastProjectPath string
 	editors := wd.getEditors(editor)
 
-	for _, project := range projects {
-		sources = wd.getSources(project, sources)
-		if len(sources) == 0 {
-			return fmt.Errorf("no GIT sources specified")
-		}
-
-		projPath := wd.projectPath(project)
-		exists, err := wd.fs.Exists(projPath)
+	targets := make([]goTarget, len(projects))
+	for i, project := range projects {
+		project, suggestions, err := wd.resolveGoProject(project, opts.Choose)
 		if err != nil {
-			log.Printf("failed to check whether \"%s\" exists: %s", project, err)
-			continue
+			return err
 		}
-		if exists {
-			lastProjectPath = projPath
-			log.Printf("\"%s\" already exists. No need to clone", project)
-			continue
+		targets[i], err = wd.resolveTarget(project, sources)
+		if err != nil {
+			return err
 		}
+		targets[i].suggestions = suggestions
+	}
 
-		err = wd.clone(project, sources)
+	started := make([]string, len(projects))
+	runParallel(len(projects), opts.Jobs, func(i int) {
+		err := wd.start(targets[i], opts.Clone)
 		if err != nil {
 			log.Println(err)
-			continue
+			return
 		}
+		name := targets[i].name
+		if opts.Worktree != "" {
+			name, err = wd.addWorktree(name, opts.Worktree)
+			if err != nil {
+				log.Println(err)
+				return
+			}
+		}
+		started[i] = name
+	})
 
-		lastProjectPath = projPath
+	var lastProject string
+	for _, name := range started {
+		if name != "" {
+			lastProject = name
+		}
 	}
 
-	if lastProjectPath == "" {
+	if lastProject == "" {
 		return fmt.Errorf("failed to start any project")
 	}
 
 	if opts.Open {
-		err := wd.open(lastProjectPath, editors)
+		err := wd.open(lastProject, editors)
 		if err != nil {
 			return err
 		}
@@ -83,7 +250,282 @@ func (wd WorkingDir) Go(projects, sources []string, editor string, opts GoOpts)
 	return nil
 }
 
-func (wd WorkingDir) Done(projects []string, opts DoneOpts) error {
+func (wd WorkingDir) PlanGo(projects, sources []string, editor string, opts GoOpts) (GoPlan, error) {
+	if len(projects) == 0 {
+		return GoPlan{}, fmt.Errorf("no projects to go specified")
+	}
+
+	plan := GoPlan{Projects: make([]ProjectPlan, len(projects))}
+	for i, project := range projects {
+		project, _, err := wd.resolveGoProject(project, opts.Choose)
+		if err != nil {
+			return GoPlan{}, err
+		}
+		target, err := wd.resolveTarget(project, sources)
+		if err != nil {
+			return GoPlan{}, err
+		}
+
+		projectPlan := ProjectPlan{
+			Project: target.name,
+			Path:    wd.projectPath(target.name),
+		}
+		projectPlan.Exists, projectPlan.Err = wd.fs.Exists(projectPlan.Path)
+		if !projectPlan.Exists {
+			for _, candidate := range target.candidates {
+				projectPlan.URLs = append(projectPlan.URLs, candidate.url)
+			}
+		}
+		if opts.Worktree != "" {
+			projectPlan.Worktree = wd.projectPath(worktreeName(target.name, opts.Worktree))
+		}
+		plan.Projects[i] = projectPlan
+	}
+
+	if opts.Open {
+		last := plan.Projects[len(projects)-1]
+		plan.Editors = wd.getEditors(editor)
+		plan.OpenPath = last.Path
+		if last.Worktree != "" {
+			plan.OpenPath = last.Worktree
+		}
+	}
+	return plan, nil
+}
+
+func (wd WorkingDir) KnownProjects() []string {
+	projects := []string{}
+	for name := range wd.config.Aliases {
+		projects = append(projects, name)
+	}
+	for name := range wd.cache.List() {
+		if _, ok := wd.config.Aliases[name]; !ok {
+			projects = append(projects, name)
+		}
+	}
+	sort.Strings(projects)
+	return projects
+}
+
+func (wd WorkingDir) Projects() ([]string, error) {
+	gitRepos, err := wd.fs.GetGitRepos(wd.directory)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(gitRepos)
+	return gitRepos, nil
+}
+
+func (wd WorkingDir) Done(projects []string, opts DoneOpts) ([]DoneResult, error) {
+	gitRepos := []string{}
+	missing := []DoneResult{}
+	if len(projects) > 0 {
+		for _, project := range projects {
+			name, suggestions, err := wd.resolveDoneProject(project, opts.Choose)
+			if err != nil {
+				return nil, err
+			}
+			if name == "" {
+				missing = append(missing, DoneResult{
+					Project: project,
+					Path:    wd.projectPath(wd.projectName(project)),
+					Outcome: DoneError,
+					Reason:  "the project is not in the working directory" + didYouMean(suggestions),
+				})
+				continue
+			}
+			if !slices.Contains(gitRepos, name) {
+				gitRepos = append(gitRepos, name)
+			}
+		}
+	} else {
+		var err error
+		gitRepos, err = wd.fs.GetGitRepos(wd.directory)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.Stale > 0 {
+		stale := wd.staleProjects(gitRepos, opts.Stale)
+		gitRepos = make([]string, len(stale))
+		for i, project := range stale {
+			gitRepos[i] = project.Name
+		}
+	}
+
+	results := make([]DoneResult, len(gitRepos))
+	runParallel(len(gitRepos), 0, func(i int) {
+		results[i] = wd.done(gitRepos[i], opts)
+	})
+	if !opts.DryRun {
+		wd.purgeTrash()
+	}
+	results = append(missing, results...)
+
+	errs := []error{}
+	for _, result := range results {
+		if result.Outcome != DoneRemoved && result.Outcome != DoneWouldRemove {
+			errs = append(errs, fmt.Errorf("\"%s\" was not removed: %s", result.Project, result.Reason))
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+func (wd WorkingDir) Sync(projects []string, opts SyncOpts) ([]SyncResult, error) {
+	gitRepos := []string{}
+	if len(projects) > 0 {
+		for _, project := range projects {
+			gitRepos = append(gitRepos, wd.projectName(project))
+		}
+	} else {
+		var err error
+		gitRepos, err = wd.Projects()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]SyncResult, len(gitRepos))
+	runParallel(len(gitRepos), opts.Jobs, func(i int) {
+		results[i] = wd.sync(gitRepos[i], opts)
+	})
+
+	errs := []error{}
+	for _, result := range results {
+		if result.Outcome == SyncError {
+			errs = append(errs, fmt.Errorf("failed to sync \"%s\": %s", result.Project, result.Reason))
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+func (wd WorkingDir) Hydrate(projects []string) error {
+	errs := make([]error, len(projects))
+	runParallel(len(projects), 0, func(i int) {
+		projectPath := wd.projectPath(projects[i])
+		exists, err := wd.fs.Exists(projectPath)
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		if !exists {
+			errs[i] = fmt.Errorf("\"%s\" does not exist", projectPath)
+			return
+		}
+		errs[i] = wd.git.Hydrate(projectPath)
+	})
+	return errors.Join(errs...)
+}
+
+func (wd WorkingDir) Restore(project string) (TrashEntry, error) {
+	if wd.trash == nil {
+		return TrashEntry{}, fmt.Errorf("the trash is not available")
+	}
+
+	entry, err := wd.trash.Find(project)
+	if err != nil {
+		return entry, err
+	}
+
+	exists, err := wd.fs.Exists(entry.OriginalPath)
+	if err != nil {
+		return entry, err
+	}
+	if exists {
+		return entry, fmt.Errorf("failed to restore \"%s\": \"%s\" already exists", project, entry.OriginalPath)
+	}
+
+	if err = wd.fs.Move(wd.trash.Path(entry), entry.OriginalPath); err != nil {
+		return entry, err
+	}
+	if err = wd.trash.Forget(entry); err != nil {
+		return entry, err
+	}
+
+	if entry.Source != "" && wd.cache.Get(project).Source == "" {
+		wd.cache.Set(project, ProjectInfo{Source: entry.Source})
+		if err = wd.cache.Write(); err != nil {
+			return entry, fmt.Errorf("restored \"%s\", but failed to remember its source: %s", project, err)
+		}
+	}
+	return entry, nil
+}
+
+// Recent returns the cached projects starting from the most recently used one. Zero limit means all of them.
+func (wd WorkingDir) Recent(limit int) []RecentProject {
+	recent := []RecentProject{}
+	for name, info := range wd.cache.List() {
+		if info.LastUsed().IsZero() {
+			continue
+		}
+		present, _ := wd.fs.Exists(wd.projectPath(name))
+		recent = append(recent, RecentProject{Name: name, ProjectInfo: info, Present: present})
+	}
+	sort.Slice(recent, func(i, j int) bool {
+		if !recent[i].LastUsed().Equal(recent[j].LastUsed()) {
+			return recent[i].LastUsed().After(recent[j].LastUsed())
+		}
+		return recent[i].Name < recent[j].Name
+	})
+	if limit > 0 && len(recent) > limit {
+		recent = recent[:limit]
+	}
+	return recent
+}
+
+// Stale returns the projects of the working directory with no activity for longer than olderThan, the least recently
+// active first. The last commit, fetch and file modification as well as the last time the project was opened count.
+func (wd WorkingDir) Stale(olderThan time.Duration) ([]StaleProject, error) {
+	projects, err := wd.Projects()
+	if err != nil {
+		return nil, err
+	}
+	return wd.staleProjects(projects, olderThan), nil
+}
+
+// PruneCache drops the cached projects which are not in the working directory and cannot be cloned again: they have
+// no source, their source is not configured anymore or their repository is unreachable.
+func (wd WorkingDir) PruneCache(opts PruneOpts) ([]PrunedProject, error) {
+	projects := wd.cache.List()
+	names := slices.Sorted(maps.Keys(projects))
+	reasons := make([]string, len(names))
+	runParallel(len(names), opts.Jobs, func(i int) {
+		reasons[i] = wd.pruneReason(names[i], projects[names[i]])
+	})
+
+	pruned := []PrunedProject{}
+	for i, name := range names {
+		if reasons[i] == "" {
+			continue
+		}
+		pruned = append(pruned, PrunedProject{Project: name, Reason: reasons[i]})
+		if !opts.DryRun {
+			wd.cache.Delete(name)
+		}
+	}
+	if len(pruned) > 0 && !opts.DryRun {
+		if err := wd.cache.Write(); err != nil {
+			return nil, err
+		}
+	}
+	return pruned, nil
+}
+
+func (wd WorkingDir) List() ([]ProjectSummary, error) {
+	gitRepos, err := wd.fs.GetGitRepos(wd.directory)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(gitRepos)
+
+	summaries := make([]ProjectSummary, len(gitRepos))
+	runParallel(len(gitRepos), 0, func(i int) {
+		summaries[i] = wd.summary(gitRepos[i])
+	})
+	return summaries, nil
+}
+
+func (wd WorkingDir) Status(projects []string) ([]ProjectStatus, error) {
 	gitRepos := []string{}
 	if len(projects) > 0 {
 		gitRepos = append(gitRepos, projects...)
@@ -91,44 +533,115 @@ func (wd WorkingDir) Done(projects []string, opts DoneOpts) error {
 		var err error
 		gitRepos, err = wd.fs.GetGitRepos(wd.directory)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		sort.Strings(gitRepos)
 	}
 
-	var wg sync.WaitGroup
-	wg.Add(len(gitRepos))
-	for _, repo := range gitRepos {
-		go func(r string) {
-			defer wg.Done()
-			wd.done(repo, opts)
-		}(repo)
+	statuses := make([]ProjectStatus, len(gitRepos))
+	runParallel(len(gitRepos), 0, func(i int) {
+		statuses[i] = wd.status(gitRepos[i])
+	})
+	return statuses, nil
+}
+
+func (wd WorkingDir) start(target goTarget, opts CloneOpts) error {
+	projPath := wd.projectPath(target.name)
+	exists, err := wd.fs.Exists(projPath)
+	if err != nil {
+		return fmt.Errorf("failed to check whether \"%s\" exists: %s", target.name, err)
+	}
+	if exists {
+		log.Printf("\"%s\" already exists. No need to clone", target.name)
+		return nil
 	}
 
-	wg.Wait()
-	return nil
+	return wd.clone(target, opts)
 }
 
-func (wd WorkingDir) clone(project string, sources []string) error {
-	for _, source := range sources {
-		err := wd.git.Clone(path.Join(source, project), wd.projectPath(project))
+func (wd WorkingDir) clone(target goTarget, opts CloneOpts) error {
+	projectPath := wd.projectPath(target.name)
+	attempts := []CloneOpts{opts}
+	if info := wd.cache.Get(target.name); opts.Branch == "" && info.Branch != "" {
+		// A done project is cloned onto the branch it was left on. The branch may be gone, so fall back to the default one
+		attempts = []CloneOpts{opts.Merge(CloneOpts{Branch: info.Branch}), opts}
+	}
+
+	for _, candidate := range target.candidates {
+		var err error
+		for i, attempt := range attempts {
+			err = wd.git.Clone(candidate.url, projectPath, wd.config.CloneOpts(candidate.source).Merge(attempt))
+			if err == nil {
+				break
+			}
+			if i < len(attempts)-1 {
+				log.Printf("%s\nTrying the default branch...", err)
+			}
+		}
 		if err != nil {
 			log.Printf("%s\nTrying other sources...", err)
 		} else {
-			wd.cache.Set(project, ProjectInfo{Source: source})
-			wd.cache.Write()
+			branch := wd.branchOf(projectPath)
+			err = wd.updateCache(target.name, func(info *ProjectInfo) {
+				info.Source = candidate.source
+				info.Owner = target.ref.Owner
+				info.Host = target.ref.Host
+				info.URL = candidate.url
+				info.ClonedAt = now()
+				if branch != "" {
+					info.Branch = branch
+				}
+			})
+			if err != nil {
+				log.Printf("%s. The source of \"%s\" is not remembered", err, target.name)
+			}
+			if err = wd.runHooks(HookPostClone, target.name, projectPath); err != nil {
+				log.Println(err)
+			}
 			return nil
 		}
 	}
 
-	return fmt.Errorf("failed to clone \"%s\". Tried all configured sources", project)
+	return fmt.Errorf("failed to clone \"%s\". Tried all configured sources%s", target.ref, didYouMean(target.suggestions))
 }
 
-func (wd WorkingDir) open(path string, editors []string) error {
+func (wd WorkingDir) addWorktree(project, branch string) (string, error) {
+	name := worktreeName(project, branch)
+	worktreePath := wd.projectPath(name)
+	exists, err := wd.fs.Exists(worktreePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to check whether \"%s\" exists: %s", name, err)
+	}
+	if exists {
+		log.Printf("\"%s\" already exists. No need to add a worktree", name)
+		return name, nil
+	}
+
+	return name, wd.git.AddWorktree(wd.projectPath(project), worktreePath, branch)
+}
+
+func (wd WorkingDir) open(project string, editors []string) error {
+	path := wd.projectPath(project)
+	if err := wd.runHooks(HookPreOpen, project, path); err != nil {
+		log.Println(err)
+	}
+
 	for _, editor_ := range editors {
 		err := wd.fs.Open(path, editor_)
 		if err != nil {
 			log.Printf("%s. Will try other editors", err)
 		} else {
+			branch := wd.branchOf(path)
+			err = wd.updateCache(project, func(info *ProjectInfo) {
+				info.LastOpenedAt = now()
+				info.OpenCount++
+				if branch != "" {
+					info.Branch = branch
+				}
+			})
+			if err != nil {
+				log.Printf("%s. The opening of \"%s\" is not recorded", err, project)
+			}
 			return nil
 		}
 	}
@@ -136,33 +649,390 @@ func (wd WorkingDir) open(path string, editors []string) error {
 	return fmt.Errorf("failed to open \"%s\". Tried all configured editors", path)
 }
 
-func (wd WorkingDir) done(project string, opts DoneOpts) {
+func (wd WorkingDir) done(project string, opts DoneOpts) DoneResult {
 	projectPath := wd.projectPath(project)
+	result := DoneResult{
+		Project: project,
+		Path:    projectPath,
+	}
 
-	if opts.Force {
+	if !opts.DryRun {
+		if err := wd.runHooks(HookPreDone, project, projectPath); err != nil {
+			if !opts.Force {
+				result.Outcome = DoneVetoed
+				result.Reason = err.Error()
+				return result
+			}
+			log.Printf("%s. Ignored as forced", err)
+		}
+	}
+
+	worktrees, err := wd.git.GetWorktrees(projectPath)
+	if err != nil && !opts.Force {
+		result.Outcome = DoneError
+		result.Reason = err.Error()
+		return result
+	}
+	mainPath := linkedWorktreeMain(worktrees)
+	if linked := linkedWorktrees(worktrees); len(linked) > 0 && !opts.Force {
+		result.Outcome = DoneKeptWorktrees
+		result.Reason = fmt.Sprintf("the project has linked worktrees: %s", strings.Join(linked, ", "))
+		return result
+	}
+
+	if opts.Force && !opts.DryRun {
 		log.Printf("forcefully removing \"%s\"", projectPath)
-		wd.removeSafe(projectPath)
-		return
+	} else {
+		policy := wd.config.DonePolicyFor(project)
+		var state GitProjectState
+		linked := mainPath != ""
+		if linked {
+			state, err = wd.git.GetWorktreeState(projectPath)
+		} else {
+			state, err = wd.git.GetProjectState(projectPath)
+		}
+		if err == nil && !linked && len(state.Commits) > 0 && (opts.DetectMerged || policy.DetectMerged) {
+			var commits []GitCommit
+			commits, err = wd.git.MarkMergedUpstream(projectPath, state.Commits)
+			if err == nil {
+				state.Commits = commits
+			}
+		}
+		if err != nil && !opts.Force {
+			result.Outcome = DoneError
+			result.Reason = err.Error()
+			return result
+		}
+		result.State, result.Ignored = policy.Apply(state)
+
+		if !opts.Force && !result.State.Clean() {
+			result.Outcome = DoneKeptDirty
+			result.Reason = "the project is not clean"
+			return result
+		}
 	}
 
+	return wd.remove(result, mainPath, opts)
+}
+
+func (wd WorkingDir) sync(project string, opts SyncOpts) SyncResult {
+	projectPath := wd.projectPath(project)
+	result := SyncResult{Project: project}
+	fail := func(err error) SyncResult {
+		result.Outcome = SyncError
+		result.Reason = err.Error()
+		return result
+	}
+
+	if err := wd.git.Fetch(projectPath); err != nil {
+		return fail(err)
+	}
+	if opts.DefaultBranch {
+		update, err := wd.git.UpdateDefaultBranch(projectPath)
+		if err != nil {
+			return fail(err)
+		}
+		result.DefaultBranch = update.Branch
+		result.DefaultBranchUpdated = update.Updated
+		result.DefaultBranchDiverged = update.Diverged
+	}
+
+	status, err := wd.git.GetBranchStatus(projectPath)
+	if err != nil {
+		return fail(err)
+	}
+	result.Branch = status.Branch
+	result.Ahead = status.Ahead
+	result.Behind = status.Behind
+
+	switch {
+	case status.Upstream == "":
+		result.Outcome = SyncNoUpstream
+	case status.Behind == 0:
+		result.Outcome = SyncUpToDate
+	case status.Ahead > 0:
+		result.Outcome = SyncDiverged
+	case status.Modified > 0:
+		result.Outcome = SyncSkippedDirty
+		result.Reason = "the project has local changes"
+	default:
+		if err = wd.git.FastForward(projectPath); err != nil {
+			return fail(err)
+		}
+		result.Outcome = SyncUpdated
+	}
+	return result
+}
+
+func (wd WorkingDir) summary(project string) ProjectSummary {
+	projectPath := wd.projectPath(project)
+	summary := ProjectSummary{
+		Name:   project,
+		Source: wd.cache.Get(project).Source,
+	}
+
+	branch, err := wd.git.GetCurrentBranch(projectPath)
+	if err != nil {
+		summary.Err = err
+		return summary
+	}
+	summary.Branch = branch
+
 	state, err := wd.git.GetProjectState(projectPath)
 	if err != nil {
-		log.Printf("failed to get state of \"%s\": %s", projectPath, err)
+		summary.Err = err
+		return summary
+	}
+	summary.Clean = state.Clean()
+
+	return summary
+}
+
+func (wd WorkingDir) status(project string) ProjectStatus {
+	projectPath := wd.projectPath(project)
+	status := ProjectStatus{Name: project}
+
+	branchStatus, err := wd.git.GetBranchStatus(projectPath)
+	if err != nil {
+		status.Err = err
+		return status
+	}
+	status.GitBranchStatus = branchStatus
+
+	status.Stashes, err = wd.git.GetStashCount(projectPath)
+	if err != nil {
+		status.Err = err
+		return status
+	}
+
+	status.UnpushedTags, err = wd.git.GetUnpushedTagCount(projectPath)
+	if err != nil {
+		status.Err = err
+		return status
+	}
+
+	return status
+}
+
+// remove moves the project to the trash. A linked worktree of the main repository at mainPath is removed by Git
+// instead: its metadata stays in the main repository, so a trashed worktree could not be restored.
+func (wd WorkingDir) remove(result DoneResult, mainPath string, opts DoneOpts) DoneResult {
+	if opts.DryRun {
+		result.Outcome = DoneWouldRemove
+		return result
+	}
+
+	branch := wd.branchOf(result.Path)
+	var err error
+	switch {
+	case mainPath != "":
+		err = wd.git.RemoveWorktree(mainPath, result.Path)
+	case wd.trash == nil:
+		err = wd.fs.Remove(result.Path)
+	default:
+		var entry TrashEntry
+		entry, err = wd.trash.Put(
+			result.Path,
+			TrashEntry{
+				Project:      result.Project,
+				OriginalPath: result.Path,
+				Source:       wd.cache.Get(result.Project).Source,
+				RemovedAt:    now(),
+			},
+		)
+		result.TrashID = entry.ID
+	}
+	if err != nil {
+		result.Outcome = DoneError
+		result.Reason = err.Error()
+		result.TrashID = ""
+		return result
+	}
+
+	result.Outcome = DoneRemoved
+	err = wd.updateCache(result.Project, func(info *ProjectInfo) {
+		info.LastDoneAt = now()
+		if branch != "" {
+			info.Branch = branch
+		}
+	})
+	if err != nil {
+		log.Printf("%s. The branch of \"%s\" is not remembered", err, result.Project)
+	}
+	if err = wd.runHooks(HookPostDone, result.Project, result.Path); err != nil {
+		log.Println(err)
+	}
+	return result
+}
+
+func (wd WorkingDir) purgeTrash() {
+	if wd.trash == nil {
 		return
 	}
+	_, err := wd.trash.Purge(wd.config.TrashRetention())
+	if err != nil {
+		log.Printf("failed to purge the trash: %s", err)
+	}
+}
+
+func (wd WorkingDir) resolveTarget(project string, sources []string) (goTarget, error) {
+	alias, isAlias := wd.config.Aliases[project]
+	if isAlias && alias.URL != "" {
+		return goTarget{
+			name:       alias.dir(project),
+			ref:        ParseProjectRef(project),
+			candidates: []cloneCandidate{{url: alias.URL}},
+		}, nil
+	}
 
-	if state.Clean() {
-		wd.removeSafe(projectPath)
+	target := goTarget{}
+	if isAlias {
+		target.name = alias.dir(project)
+		target.ref = wd.resolveRef(alias.remote(project))
+		if alias.Source != "" {
+			sources = []string{alias.Source}
+		} else {
+			sources = wd.getSources(target.name, sources)
+		}
 	} else {
-		log.Printf("\"%s\" will not be removed: the project is not clean:\n%s", projectPath, state)
+		target.ref = wd.resolveRef(project)
+		target.name = target.ref.Name
+		sources = wd.getSources(target.name, sources)
 	}
+	if len(sources) == 0 {
+		return target, fmt.Errorf("no GIT sources specified")
+	}
+
+	for _, source := range sources {
+		url, err := ExpandSource(source, target.ref)
+		if err != nil {
+			log.Printf("%s. Will be skipped", err)
+			continue
+		}
+		target.candidates = append(target.candidates, cloneCandidate{source: source, url: url})
+	}
+	return target, nil
+}
+
+func (wd WorkingDir) resolveRef(project string) ProjectRef {
+	ref := ParseProjectRef(project)
+	info := wd.cache.Get(ref.Name)
+	if ref.Owner == "" {
+		ref.Owner = info.Owner
+	}
+	if ref.Host == "" {
+		ref.Host = info.Host
+	}
+	return ref
+}
+
+func (wd WorkingDir) projectName(project string) string {
+	if alias, ok := wd.config.Aliases[project]; ok {
+		return alias.dir(project)
+	}
+	return ParseProjectRef(project).Name
+}
+
+func (wd WorkingDir) staleProjects(projects []string, olderThan time.Duration) []StaleProject {
+	activities := make([]ProjectActivity, len(projects))
+	errs := make([]error, len(projects))
+	runParallel(len(projects), 0, func(i int) {
+		activities[i], errs[i] = wd.activity(projects[i])
+	})
+
+	threshold := now().Add(-olderThan)
+	stale := []StaleProject{}
+	for i, project := range projects {
+		// A project whose activity is unknown is never considered stale
+		if errs[i] != nil {
+			log.Println(errs[i])
+			continue
+		}
+		if activities[i].Last().Before(threshold) {
+			stale = append(stale, StaleProject{Name: project, Activity: activities[i]})
+		}
+	}
+	sort.SliceStable(stale, func(i, j int) bool {
+		return stale[i].Activity.Last().Before(stale[j].Activity.Last())
+	})
+	return stale
 }
 
-func (wd WorkingDir) removeSafe(path string) {
-	err := wd.fs.Remove(path)
+func (wd WorkingDir) activity(project string) (ProjectActivity, error) {
+	projectPath := wd.projectPath(project)
+	gitActivity, err := wd.git.GetActivity(projectPath)
+	if err != nil {
+		return ProjectActivity{}, err
+	}
+	modified, err := wd.fs.LastModified(projectPath)
 	if err != nil {
-		log.Printf("failed to remove \"%s\": %s", path, err)
+		return ProjectActivity{}, err
 	}
+	return ProjectActivity{
+		LastCommit:   gitActivity.LastCommit,
+		LastFetch:    gitActivity.LastFetch,
+		LastModified: modified,
+		LastOpened:   wd.cache.Get(project).LastOpenedAt,
+	}, nil
+}
+
+// pruneReason returns why the cached project should be pruned or an empty string if it should be kept.
+func (wd WorkingDir) pruneReason(project string, info ProjectInfo) string {
+	if exists, err := wd.fs.Exists(wd.projectPath(project)); err != nil || exists {
+		return ""
+	}
+	if info.Source == "" && info.URL == "" {
+		return "no source"
+	}
+	if info.Source != "" && !wd.isConfiguredSource(info.Source) {
+		return fmt.Sprintf("the source \"%s\" is not configured", info.Source)
+	}
+
+	url := info.URL
+	if url == "" {
+		var err error
+		url, err = ExpandSource(info.Source, ProjectRef{Name: project, Owner: info.Owner, Host: info.Host})
+		if err != nil {
+			return err.Error()
+		}
+	}
+	if err := wd.git.CheckRemote(url); err != nil {
+		log.Println(err)
+		return fmt.Sprintf("the repository \"%s\" is unreachable", url)
+	}
+	return ""
+}
+
+func (wd WorkingDir) isConfiguredSource(source string) bool {
+	if slices.Contains(wd.config.Sources, source) {
+		return true
+	}
+	if _, ok := wd.config.SourceSettings[source]; ok {
+		return true
+	}
+	for _, alias := range wd.config.Aliases {
+		if alias.Source == source {
+			return true
+		}
+	}
+	return false
+}
+
+// updateCache applies the update to the cached info of the project and writes the cache.
+func (wd WorkingDir) updateCache(project string, update func(info *ProjectInfo)) error {
+	info := wd.cache.Get(project)
+	update(&info)
+	wd.cache.Set(project, info)
+	return wd.cache.Write()
+}
+
+// branchOf returns the checked out branch of the project or an empty string if it is unknown or detached.
+func (wd WorkingDir) branchOf(projectPath string) string {
+	branch, err := wd.git.GetCurrentBranch(projectPath)
+	if err != nil || branch == "HEAD" {
+		return ""
+	}
+	return branch
 }
 
 func (wd WorkingDir) projectPath(name string) string {
@@ -170,12 +1040,30 @@ func (wd WorkingDir) projectPath(name string) string {
 }
 
 func (wd WorkingDir) getEditors(editor string) []string {
+	return editorsFor(editor, wd.config.Editor)
+}
+
+func (wd WorkingDir) getSources(project string, sources []string) []string {
+	mergedSources := []string{}
+	projectCacheInfo := wd.cache.Get(project)
+
+	mergedSources = append(mergedSources, sources...)
+
+	if projectCacheInfo.Source != "" {
+		mergedSources = append(mergedSources, projectCacheInfo.Source)
+	}
+
+	mergedSources = append(mergedSources, wd.config.Sources...)
+	return mergedSources
+}
+
+func editorsFor(editor, configEditor string) []string {
 	editors := []string{}
 	if editor != "" {
 		editors = append(editors, editor)
 	}
-	if wd.config.Editor != "" {
-		editors = append(editors, wd.config.Editor)
+	if configEditor != "" {
+		editors = append(editors, configEditor)
 	}
 
 	envEditor, envEditorSet := os.LookupEnv("EDITOR")
@@ -186,16 +1074,54 @@ func (wd WorkingDir) getEditors(editor string) []string {
 	return editors
 }
 
-func (wd WorkingDir) getSources(project string, sources []string) []string {
-	mergedSources := []string{}
-	projectCacheInfo := wd.cache.Get(project)
+func worktreeName(project, branch string) string {
+	return project + "-" + strings.ReplaceAll(branch, "/", "-")
+}
 
-	mergedSources = append(mergedSources, sources...)
+// linkedWorktreeMain returns the path of the main repository if the current worktree is a linked one.
+func linkedWorktreeMain(worktrees []GitWorktree) string {
+	var main string
+	for _, worktree := range worktrees {
+		if worktree.Main {
+			main = worktree.Path
+		} else if worktree.Current {
+			return main
+		}
+	}
+	return ""
+}
 
-	if projectCacheInfo.Source != "" {
-		mergedSources = append(mergedSources, projectCacheInfo.Source)
+// linkedWorktrees returns the linked worktrees which still exist if the current worktree is the main one.
+func linkedWorktrees(worktrees []GitWorktree) []string {
+	linked := []string{}
+	for _, worktree := range worktrees {
+		if worktree.Main && !worktree.Current {
+			return nil
+		}
+		if !worktree.Main && !worktree.Prunable {
+			linked = append(linked, worktree.Path)
+		}
 	}
+	return linked
+}
 
-	mergedSources = append(mergedSources, wd.config.Sources...)
-	return mergedSources
+func runParallel(count, jobs int, fn func(i int)) {
+	if jobs <= 0 {
+		jobs = count
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, jobs)
+	wg.Add(count)
+	for i := 0; i < count; i++ {
+		slots <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
 }
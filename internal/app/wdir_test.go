@@ -1,25 +1,36 @@
 package app
 
 import (
+	"errors"
 	"fmt"
+	"maps"
 	"path"
 	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
 
+var dirtyChanges = []GitFileChange{{Status: "??", Path: "file"}}
+
 type wdComponents struct {
-	dir   string
-	fs    FileSystem
-	git   Git
-	cache ICache
+	dir    string
+	fs     FileSystem
+	git    Git
+	cache  ICache
+	trash  ITrash
+	config *Config
+	cmd    CMD
 }
 
 type FakeRepo struct {
 	path       string
 	opensCount int
+	modified   time.Time
 }
 
 type FakeEditor struct{}
@@ -32,12 +43,14 @@ func (f FakeEditor) Open(repo *FakeRepo) error {
 type FakeFS struct {
 	repos   map[string]*FakeRepo
 	editors map[string]FakeEditor
+	mu      *sync.Mutex
 }
 
 func NewFakeFS() *FakeFS {
 	return &FakeFS{
 		repos:   map[string]*FakeRepo{},
 		editors: map[string]FakeEditor{"vi": {}},
+		mu:      &sync.Mutex{},
 	}
 }
 
@@ -52,6 +65,8 @@ func (f FakeFS) WithEditors(editors map[string]FakeEditor) *FakeFS {
 }
 
 func (f *FakeFS) Exists(path string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
 	if _, ok := f.repos[path]; ok {
 		return true, nil
 	}
@@ -70,9 +85,37 @@ func (f *FakeFS) Open(path, editor string) error {
 	return fmt.Errorf("unknown repo: %s", path)
 }
 func (f *FakeFS) Remove(path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
 	delete(f.repos, path)
 	return nil
 }
+func (f *FakeFS) Move(src, dst string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	repo, ok := f.repos[src]
+	if !ok {
+		return fmt.Errorf("unknown repo: %s", src)
+	}
+	delete(f.repos, src)
+	repo.path = dst
+	f.repos[dst] = repo
+	return nil
+}
+func (f *FakeFS) LastModified(path string) (time.Time, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	repo, ok := f.repos[path]
+	if !ok {
+		return time.Time{}, fmt.Errorf("unknown repo: %s", path)
+	}
+	return repo.modified, nil
+}
+func (f *FakeFS) addRepo(path string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.repos[path] = &FakeRepo{path: path}
+}
 func (f *FakeFS) GetGitRepos(dir string) ([]string, error) {
 	repos := []string{}
 	for _, repo := range f.repos {
@@ -85,15 +128,35 @@ func (f *FakeFS) GetGitRepos(dir string) ([]string, error) {
 }
 
 type FakeGit struct {
-	states  map[string]GitProjectState
-	fs      FakeFS
-	sources []string
+	states         map[string]GitProjectState
+	worktreeStates map[string]GitProjectState
+	stateErrors    map[string]error
+	branches       map[string]string
+	branchStatuses map[string]GitBranchStatus
+	fs             FakeFS
+	sources        []string
+	clones         map[string]CloneOpts
+	hydrated       []string
+	merged         []string
+	worktrees      map[string][]GitWorktree
+	addedWorktrees map[string]string
+	removedTrees   []string
+	fetchErrors    map[string]error
+	fetched        []string
+	fastForwarded  []string
+	unreachable    []string
+	activities     map[string]GitActivity
+	defaultUpdates map[string]GitDefaultBranchUpdate
+	mu             sync.Mutex
 }
 
 func NewFakeGit(fs *FakeFS) *FakeGit {
 	return &FakeGit{
-		states: map[string]GitProjectState{},
-		fs:     *fs,
+		states:         map[string]GitProjectState{},
+		branches:       map[string]string{},
+		fs:             *fs,
+		clones:         map[string]CloneOpts{},
+		addedWorktrees: map[string]string{},
 	}
 }
 
@@ -102,45 +165,190 @@ func (fg *FakeGit) WithStates(states map[string]GitProjectState) *FakeGit {
 	return fg
 }
 
+func (fg *FakeGit) WithWorktreeStates(states map[string]GitProjectState) *FakeGit {
+	fg.worktreeStates = states
+	return fg
+}
+
+func (fg *FakeGit) WithStateErrors(errors map[string]error) *FakeGit {
+	fg.stateErrors = errors
+	return fg
+}
+
+func (fg *FakeGit) WithBranches(branches map[string]string) *FakeGit {
+	fg.branches = branches
+	return fg
+}
+
+func (fg *FakeGit) WithBranchStatuses(statuses map[string]GitBranchStatus) *FakeGit {
+	fg.branchStatuses = statuses
+	return fg
+}
+
+func (fg *FakeGit) WithMerged(hashes []string) *FakeGit {
+	fg.merged = hashes
+	return fg
+}
+
+func (fg *FakeGit) WithWorktrees(worktrees map[string][]GitWorktree) *FakeGit {
+	fg.worktrees = worktrees
+	return fg
+}
+
+func (fg *FakeGit) WithFetchErrors(errors map[string]error) *FakeGit {
+	fg.fetchErrors = errors
+	return fg
+}
+
+func (fg *FakeGit) WithDefaultBranchUpdates(updates map[string]GitDefaultBranchUpdate) *FakeGit {
+	fg.defaultUpdates = updates
+	return fg
+}
+
+func (fg *FakeGit) WithUnreachable(urls []string) *FakeGit {
+	fg.unreachable = urls
+	return fg
+}
+
+func (fg *FakeGit) WithActivities(activities map[string]GitActivity) *FakeGit {
+	fg.activities = activities
+	return fg
+}
+
 func (fg *FakeGit) WithSources(sources []string) *FakeGit {
 	fg.sources = sources
 	return fg
 }
 
 func (fg *FakeGit) GetProjectState(path string) (GitProjectState, error) {
+	if err, ok := fg.stateErrors[path]; ok {
+		return GitProjectState{}, err
+	}
 	if state, ok := fg.states[path]; ok {
 		return state, nil
 	}
 	return GitProjectState{}, nil
 }
-func (fg *FakeGit) Clone(source, destination string) error {
+func (fg *FakeGit) GetWorktreeState(path string) (GitProjectState, error) {
+	return fg.worktreeStates[path], nil
+}
+func (fg *FakeGit) Clone(source, destination string, opts CloneOpts) error {
 	if !slices.Contains(fg.sources, source) {
 		return fmt.Errorf("source \"%s\" not found", source)
 	}
-	fg.fs.repos[destination] = &FakeRepo{path: destination}
+	fg.mu.Lock()
+	fg.clones[destination] = opts
+	fg.mu.Unlock()
+	fg.fs.addRepo(destination)
+	return nil
+}
+func (fg *FakeGit) Hydrate(path string) error {
+	fg.mu.Lock()
+	defer fg.mu.Unlock()
+	fg.hydrated = append(fg.hydrated, path)
+	return nil
+}
+
+func (fg *FakeGit) GetCurrentBranch(path string) (string, error) {
+	if branch, ok := fg.branches[path]; ok {
+		return branch, nil
+	}
+	return "main", nil
+}
+
+func (fg *FakeGit) GetBranchStatus(path string) (GitBranchStatus, error) {
+	if status, ok := fg.branchStatuses[path]; ok {
+		return status, nil
+	}
+	return GitBranchStatus{}, fmt.Errorf("unknown repo: %s", path)
+}
+func (fg *FakeGit) GetStashCount(path string) (int, error) {
+	return len(fg.states[path].Stashes), nil
+}
+func (fg *FakeGit) GetUnpushedTagCount(path string) (int, error) {
+	return len(fg.states[path].Tags), nil
+}
+func (fg *FakeGit) GetWorktrees(path string) ([]GitWorktree, error) {
+	return fg.worktrees[path], nil
+}
+func (fg *FakeGit) AddWorktree(path, worktreePath, branch string) error {
+	fg.mu.Lock()
+	fg.addedWorktrees[worktreePath] = branch
+	fg.mu.Unlock()
+	fg.fs.addRepo(worktreePath)
+	return nil
+}
+func (fg *FakeGit) RemoveWorktree(path, worktreePath string) error {
+	fg.mu.Lock()
+	fg.removedTrees = append(fg.removedTrees, path+":"+worktreePath)
+	fg.mu.Unlock()
+	return fg.fs.Remove(worktreePath)
+}
+func (fg *FakeGit) Fetch(path string) error {
+	if err, ok := fg.fetchErrors[path]; ok {
+		return err
+	}
+	fg.mu.Lock()
+	defer fg.mu.Unlock()
+	fg.fetched = append(fg.fetched, path)
+	return nil
+}
+func (fg *FakeGit) FastForward(path string) error {
+	fg.mu.Lock()
+	defer fg.mu.Unlock()
+	fg.fastForwarded = append(fg.fastForwarded, path)
+	return nil
+}
+func (fg *FakeGit) CheckRemote(url string) error {
+	if slices.Contains(fg.unreachable, url) {
+		return fmt.Errorf("failed to reach \"%s\"", url)
+	}
 	return nil
 }
+func (fg *FakeGit) GetActivity(path string) (GitActivity, error) {
+	return fg.activities[path], nil
+}
+func (fg *FakeGit) UpdateDefaultBranch(path string) (GitDefaultBranchUpdate, error) {
+	if update, ok := fg.defaultUpdates[path]; ok {
+		return update, nil
+	}
+	return GitDefaultBranchUpdate{Branch: "main", Updated: true}, nil
+}
+func (fg *FakeGit) MarkMergedUpstream(path string, commits []GitCommit) ([]GitCommit, error) {
+	marked := make([]GitCommit, len(commits))
+	for i, commit := range commits {
+		commit.MergedUpstream = slices.Contains(fg.merged, commit.Hash)
+		marked[i] = commit
+	}
+	return marked, nil
+}
 
 type FakeCache struct {
 	Cache
-	Writes int
+	Writes   int
+	WriteErr error
 }
 
-func (fc *FakeCache) Write() {
+func (fc *FakeCache) Write() error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
 	fc.Writes++
+	return fc.WriteErr
 }
 
 func NewEmptyFakeCache() *FakeCache {
 	return &FakeCache{
 		Cache: Cache{
-			Data: map[string]ProjectInfo{},
+			data:  map[string]ProjectInfo{},
+			dirty: map[string]bool{},
 		},
 	}
 }
 func NewFakeCache(data map[string]ProjectInfo) *FakeCache {
 	return &FakeCache{
 		Cache: Cache{
-			Data: data,
+			data:  data,
+			dirty: map[string]bool{},
 		},
 	}
 }
@@ -152,15 +360,32 @@ func buildWorkingDir(comps wdComponents) WorkingDir {
 	if comps.cache == nil {
 		comps.cache = NewEmptyFakeCache()
 	}
+	if comps.config == nil {
+		config := NewDefaultConfig()
+		comps.config = &config
+	}
+	if comps.cmd == nil {
+		comps.cmd = &FakeCMD{}
+	}
 	return WorkingDir{
 		directory: comps.dir,
 		fs:        comps.fs,
 		git:       comps.git,
-		config:    NewDefaultConfig(),
+		config:    *comps.config,
 		cache:     comps.cache,
+		trash:     comps.trash,
+		cmd:       comps.cmd,
 	}
 }
 
+func freezeNow(t *testing.T) time.Time {
+	frozen := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	oldNow := now
+	now = func() time.Time { return frozen }
+	t.Cleanup(func() { now = oldNow })
+	return frozen
+}
+
 func TestGo(t *testing.T) {
 	t.Run("no project; cloned; not opened", func(t *testing.T) {
 		fs := NewFakeFS()
@@ -223,7 +448,7 @@ func TestGo(t *testing.T) {
 		require.Error(t, err)
 	})
 	t.Run("sources are empty", func(t *testing.T) {
-		wd := buildWorkingDir(wdComponents{})
+		wd := buildWorkingDir(wdComponents{fs: NewFakeFS()})
 		err := wd.Go([]string{"p1"}, []string{}, "", GoOpts{})
 		require.Error(t, err)
 	})
@@ -250,6 +475,7 @@ func TestGo(t *testing.T) {
 		require.Equal(t, cache.Writes, 1)
 	})
 	t.Run("cloned source cached", func(t *testing.T) {
+		cloned := freezeNow(t)
 		fs := NewFakeFS()
 		git := NewFakeGit(fs).WithSources([]string{"s/p1"})
 		cache := NewFakeCache(map[string]ProjectInfo{})
@@ -261,10 +487,484 @@ func TestGo(t *testing.T) {
 
 		err := wd.Go([]string{"p1"}, []string{"s"}, "", GoOpts{})
 		require.NoError(t, err)
-		require.Equal(t, cache.Data, map[string]ProjectInfo{"p1": {Source: "s"}})
+		require.Equal(
+			t,
+			cache.data,
+			map[string]ProjectInfo{"p1": {Source: "s", URL: "s/p1", Branch: "main", ClonedAt: cloned}},
+		)
+	})
+	t.Run("many projects; cloned in parallel", func(t *testing.T) {
+		cloned := freezeNow(t)
+		fs := NewFakeFS()
+		git := NewFakeGit(fs).WithSources(
+			[]string{"s1/p1", "s2/p2", "s1/p3", "s2/p4", "s1/p5"},
+		)
+		cache := NewEmptyFakeCache()
+		wd := buildWorkingDir(wdComponents{
+			fs:    fs,
+			git:   git,
+			cache: cache,
+		})
+
+		err := wd.Go(
+			[]string{"p1", "p2", "p3", "p4", "p5"},
+			[]string{"s1", "s2"},
+			"",
+			GoOpts{Jobs: 2},
+		)
+		require.NoError(t, err)
+		require.Len(t, fs.repos, 5)
+		require.Equal(t, cache.Writes, 5)
+		require.Equal(
+			t,
+			cache.data,
+			map[string]ProjectInfo{
+				"p1": {Source: "s1", URL: "s1/p1", Branch: "main", ClonedAt: cloned},
+				"p2": {Source: "s2", URL: "s2/p2", Branch: "main", ClonedAt: cloned},
+				"p3": {Source: "s1", URL: "s1/p3", Branch: "main", ClonedAt: cloned},
+				"p4": {Source: "s2", URL: "s2/p4", Branch: "main", ClonedAt: cloned},
+				"p5": {Source: "s1", URL: "s1/p5", Branch: "main", ClonedAt: cloned},
+			},
+		)
+	})
+	t.Run("many projects; last started one opened", func(t *testing.T) {
+		fs := NewFakeFS().WithEditors(map[string]FakeEditor{"vim": {}})
+		git := NewFakeGit(fs).WithSources([]string{"s/p1", "s/p2"})
+		wd := buildWorkingDir(wdComponents{
+			fs:  fs,
+			git: git,
+		})
+
+		err := wd.Go([]string{"p1", "p2", "unknown"}, []string{"s"}, "vim", GoOpts{Open: true, Jobs: 3})
+		require.NoError(t, err)
+		require.Equal(t, fs.repos["/dwd/p1"].opensCount, 0)
+		require.Equal(t, fs.repos["/dwd/p2"].opensCount, 1)
+	})
+}
+
+func TestProjectHistory(t *testing.T) {
+	t.Run("opened and done; recorded", func(t *testing.T) {
+		used := freezeNow(t)
+		fs := NewFakeFS().WithEditors(map[string]FakeEditor{"vim": {}}).WithRepos(
+			map[string]*FakeRepo{"/dwd/proj": {path: "/dwd/proj"}},
+		)
+		git := NewFakeGit(fs).WithBranches(map[string]string{"/dwd/proj": "feature"})
+		cache := NewFakeCache(map[string]ProjectInfo{"proj": {Source: "s", OpenCount: 2}})
+		wd := buildWorkingDir(wdComponents{fs: fs, git: git, cache: cache})
+
+		require.NoError(t, wd.Go([]string{"proj"}, []string{}, "vim", GoOpts{Open: true}))
+		require.Equal(t, ProjectInfo{Source: "s", Branch: "feature", LastOpenedAt: used, OpenCount: 3}, cache.data["proj"])
+
+		_, err := wd.Done([]string{"proj"}, DoneOpts{})
+		require.NoError(t, err)
+		require.Equal(t, used, cache.data["proj"].LastDoneAt)
+	})
+	t.Run("dry run; not recorded", func(t *testing.T) {
+		fs := NewFakeFS().WithRepos(map[string]*FakeRepo{"/dwd/proj": {path: "/dwd/proj"}})
+		cache := NewEmptyFakeCache()
+		wd := buildWorkingDir(wdComponents{fs: fs, git: NewFakeGit(fs), cache: cache})
+
+		_, err := wd.Done([]string{"proj"}, DoneOpts{DryRun: true})
+		require.NoError(t, err)
+		require.Empty(t, cache.data)
+	})
+	t.Run("done project; cloned onto the last branch", func(t *testing.T) {
+		fs := NewFakeFS()
+		git := NewFakeGit(fs).WithSources([]string{"s/proj"})
+		cache := NewFakeCache(map[string]ProjectInfo{"proj": {Source: "s", Branch: "feature", OpenCount: 1}})
+		wd := buildWorkingDir(wdComponents{fs: fs, git: git, cache: cache})
+
+		require.NoError(t, wd.Go([]string{"proj"}, []string{}, "", GoOpts{}))
+		require.Equal(t, "feature", git.clones["/dwd/proj"].Branch)
+		require.Equal(t, 1, cache.data["proj"].OpenCount)
+	})
+	t.Run("explicit branch; preferred", func(t *testing.T) {
+		fs := NewFakeFS()
+		git := NewFakeGit(fs).WithSources([]string{"s/proj"})
+		cache := NewFakeCache(map[string]ProjectInfo{"proj": {Source: "s", Branch: "feature"}})
+		wd := buildWorkingDir(wdComponents{fs: fs, git: git, cache: cache})
+
+		require.NoError(t, wd.Go([]string{"proj"}, []string{}, "", GoOpts{Clone: CloneOpts{Branch: "main"}}))
+		require.Equal(t, "main", git.clones["/dwd/proj"].Branch)
+	})
+}
+
+func TestRecent(t *testing.T) {
+	day := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	fs := NewFakeFS().WithRepos(map[string]*FakeRepo{"/dwd/p2": {path: "/dwd/p2"}})
+	cache := NewFakeCache(map[string]ProjectInfo{
+		"p1":    {ClonedAt: day, LastDoneAt: day.Add(2 * time.Hour)},
+		"p2":    {ClonedAt: day, LastOpenedAt: day.Add(3 * time.Hour)},
+		"p3":    {ClonedAt: day.Add(time.Hour)},
+		"never": {Source: "s"},
+	})
+	wd := buildWorkingDir(wdComponents{fs: fs, cache: cache})
+
+	recent := wd.Recent(0)
+	names := []string{}
+	for _, project := range recent {
+		names = append(names, project.Name)
+	}
+	require.Equal(t, []string{"p2", "p1", "p3"}, names)
+	require.True(t, recent[0].Present)
+	require.False(t, recent[1].Present)
+	require.Len(t, wd.Recent(2), 2)
+}
+
+func TestStale(t *testing.T) {
+	current := freezeNow(t)
+	daysAgo := func(days int) time.Time { return current.AddDate(0, 0, -days) }
+	fs := NewFakeFS().WithRepos(map[string]*FakeRepo{
+		"/dwd/old":       {path: "/dwd/old", modified: daysAgo(50)},
+		"/dwd/older":     {path: "/dwd/older", modified: daysAgo(90)},
+		"/dwd/edited":    {path: "/dwd/edited", modified: daysAgo(2)},
+		"/dwd/fetched":   {path: "/dwd/fetched", modified: daysAgo(90)},
+		"/dwd/committed": {path: "/dwd/committed", modified: daysAgo(90)},
+		"/dwd/opened":    {path: "/dwd/opened", modified: daysAgo(90)},
+	})
+	git := NewFakeGit(fs).WithActivities(map[string]GitActivity{
+		"/dwd/old":       {LastCommit: daysAgo(60), LastFetch: daysAgo(40)},
+		"/dwd/fetched":   {LastCommit: daysAgo(90), LastFetch: daysAgo(1)},
+		"/dwd/committed": {LastCommit: daysAgo(3)},
+	})
+	cache := NewFakeCache(map[string]ProjectInfo{"opened": {LastOpenedAt: daysAgo(5)}})
+	wd := buildWorkingDir(wdComponents{fs: fs, git: git, cache: cache})
+
+	stale, err := wd.Stale(30 * 24 * time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, []StaleProject{
+		{Name: "older", Activity: ProjectActivity{LastModified: daysAgo(90)}},
+		{Name: "old", Activity: ProjectActivity{LastCommit: daysAgo(60), LastFetch: daysAgo(40), LastModified: daysAgo(50)}},
+	}, stale)
+
+	stale, err = wd.Stale(100 * 24 * time.Hour)
+	require.NoError(t, err)
+	require.Empty(t, stale)
+
+	t.Run("done", func(t *testing.T) {
+		git.states = map[string]GitProjectState{"/dwd/old": {Changes: dirtyChanges}}
+
+		results, err := wd.Done([]string{}, DoneOpts{Stale: 30 * 24 * time.Hour})
+		require.Error(t, err)
+		require.Len(t, results, 2)
+		require.Equal(t, DoneRemoved, results[0].Outcome)
+		require.Equal(t, "older", results[0].Project)
+		require.Equal(t, DoneKeptDirty, results[1].Outcome)
+		require.Equal(t, []string{"committed", "edited", "fetched", "old", "opened"}, slices.Sorted(maps.Keys(reposByName(fs))))
+	})
+	t.Run("done; only passed projects", func(t *testing.T) {
+		results, err := wd.Done([]string{"edited", "old"}, DoneOpts{Stale: 30 * 24 * time.Hour, DryRun: true})
+		require.Error(t, err)
+		require.Len(t, results, 1)
+		require.Equal(t, "old", results[0].Project)
+	})
+}
+
+func reposByName(fs *FakeFS) map[string]*FakeRepo {
+	repos := map[string]*FakeRepo{}
+	for repoPath, repo := range fs.repos {
+		repos[path.Base(repoPath)] = repo
+	}
+	return repos
+}
+
+func TestPruneCache(t *testing.T) {
+	config := NewDefaultConfig()
+	config.Sources = []string{"s", "git@host:{owner}/{project}.git"}
+	config.Aliases = map[string]Alias{"api": {Source: "alias-source"}}
+	newCache := func() *FakeCache {
+		return NewFakeCache(map[string]ProjectInfo{
+			"present":      {Source: "removed"},
+			"reachable":    {Source: "s"},
+			"template":     {Source: "git@host:{owner}/{project}.git", Owner: "team"},
+			"alias":        {Source: "alias-source"},
+			"url":          {URL: "git@host:url.git"},
+			"no-source":    {OpenCount: 1},
+			"unconfigured": {Source: "removed"},
+			"unreachable":  {Source: "s"},
+			"bad-template": {Source: "git@host:{owner}/{project}.git"},
+		})
+	}
+	fs := NewFakeFS().WithRepos(map[string]*FakeRepo{"/dwd/present": {path: "/dwd/present"}})
+	git := NewFakeGit(fs).WithUnreachable([]string{"s/unreachable"})
+	expected := []PrunedProject{
+		{Project: "bad-template", Reason: "source \"git@host:{owner}/{project}.git\" requires {owner} for \"bad-template\""},
+		{Project: "no-source", Reason: "no source"},
+		{Project: "unconfigured", Reason: "the source \"removed\" is not configured"},
+		{Project: "unreachable", Reason: "the repository \"s/unreachable\" is unreachable"},
+	}
+
+	t.Run("pruned", func(t *testing.T) {
+		cache := newCache()
+		wd := buildWorkingDir(wdComponents{fs: fs, git: git, cache: cache, config: &config})
+
+		pruned, err := wd.PruneCache(PruneOpts{Jobs: 3})
+		require.NoError(t, err)
+		require.Equal(t, expected, pruned)
+		require.Equal(t, []string{"alias", "present", "reachable", "template", "url"}, slices.Sorted(maps.Keys(cache.data)))
+		require.Equal(t, 1, cache.Writes)
+	})
+	t.Run("write failed", func(t *testing.T) {
+		cache := newCache()
+		cache.WriteErr = errors.New("lock timeout")
+		wd := buildWorkingDir(wdComponents{fs: fs, git: git, cache: cache, config: &config})
+
+		_, err := wd.PruneCache(PruneOpts{})
+		require.ErrorContains(t, err, "lock timeout")
+	})
+	t.Run("dry run; kept", func(t *testing.T) {
+		cache := newCache()
+		wd := buildWorkingDir(wdComponents{fs: fs, git: git, cache: cache, config: &config})
+
+		pruned, err := wd.PruneCache(PruneOpts{DryRun: true})
+		require.NoError(t, err)
+		require.Equal(t, expected, pruned)
+		require.Len(t, cache.data, 9)
+		require.Equal(t, 0, cache.Writes)
+	})
+}
+
+func TestGoSourceTemplates(t *testing.T) {
+	t.Run("owner; template", func(t *testing.T) {
+		cloned := freezeNow(t)
+		fs := NewFakeFS()
+		git := NewFakeGit(fs).WithSources([]string{"git@host:group/sub/proj.git"})
+		cache := NewEmptyFakeCache()
+		wd := buildWorkingDir(wdComponents{
+			fs:    fs,
+			git:   git,
+			cache: cache,
+		})
+
+		err := wd.Go(
+			[]string{"group/sub/proj"},
+			[]string{"git@other:{project}", "git@host:{owner}/{project}.git"},
+			"",
+			GoOpts{},
+		)
+		require.NoError(t, err)
+		_, ok := fs.repos["/dwd/proj"]
+		require.True(t, ok)
+		require.Equal(
+			t,
+			map[string]ProjectInfo{
+				"proj": {
+					Source:   "git@host:{owner}/{project}.git",
+					Owner:    "group/sub",
+					URL:      "git@host:group/sub/proj.git",
+					Branch:   "main",
+					ClonedAt: cloned,
+				},
+			},
+			cache.data,
+		)
+	})
+	t.Run("template requires owner; skipped", func(t *testing.T) {
+		fs := NewFakeFS()
+		git := NewFakeGit(fs).WithSources([]string{"s/proj"})
+		wd := buildWorkingDir(wdComponents{
+			fs:  fs,
+			git: git,
+		})
+
+		err := wd.Go([]string{"proj"}, []string{"git@host:{owner}/{project}.git", "s"}, "", GoOpts{})
+		require.NoError(t, err)
+		_, ok := fs.repos["/dwd/proj"]
+		require.True(t, ok)
+	})
+	t.Run("owner from cache", func(t *testing.T) {
+		fs := NewFakeFS()
+		git := NewFakeGit(fs).WithSources([]string{"https://github.com/pallets/flask"})
+		cache := NewFakeCache(
+			map[string]ProjectInfo{
+				"flask": {Source: "https://{host}/{owner}/{project}", Owner: "pallets", Host: "github.com"},
+			},
+		)
+		wd := buildWorkingDir(wdComponents{
+			fs:    fs,
+			git:   git,
+			cache: cache,
+		})
+
+		err := wd.Go([]string{"flask"}, []string{}, "", GoOpts{})
+		require.NoError(t, err)
+		_, ok := fs.repos["/dwd/flask"]
+		require.True(t, ok)
+	})
+	t.Run("done by owner/project", func(t *testing.T) {
+		fs := NewFakeFS().WithRepos(
+			map[string]*FakeRepo{"/dwd/proj": {path: "/dwd/proj"}},
+		)
+		wd := buildWorkingDir(wdComponents{
+			fs:  fs,
+			git: NewFakeGit(fs),
+		})
+
+		results, err := wd.Done([]string{"group/proj"}, DoneOpts{})
+		require.NoError(t, err)
+		require.Equal(t, "proj", results[0].Project)
+		require.Empty(t, fs.repos)
+	})
+}
+
+func TestAliases(t *testing.T) {
+	config := NewDefaultConfig()
+	config.Sources = []string{"s"}
+	config.Aliases = map[string]Alias{
+		"billing": {URL: "git@host:platform/platform-billing-service-v2.git"},
+		"auth":    {Source: "git@host:{owner}/{project}.git", Remote: "platform/auth-service", Dir: "auth-svc"},
+		"web":     {Remote: "frontend-web"},
+	}
+
+	tests := map[string]struct {
+		project string
+		url     string
+		dir     string
+		info    ProjectInfo
+	}{
+		"url": {
+			project: "billing",
+			url:     "git@host:platform/platform-billing-service-v2.git",
+			dir:     "/dwd/billing",
+			info:    ProjectInfo{},
+		},
+		"source and remote": {
+			project: "auth",
+			url:     "git@host:platform/auth-service.git",
+			dir:     "/dwd/auth-svc",
+			info:    ProjectInfo{Source: "git@host:{owner}/{project}.git", Owner: "platform"},
+		},
+		"remote with configured sources": {
+			project: "web",
+			url:     "s/frontend-web",
+			dir:     "/dwd/web",
+			info:    ProjectInfo{Source: "s"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cloned := freezeNow(t)
+			fs := NewFakeFS()
+			git := NewFakeGit(fs).WithSources([]string{test.url})
+			cache := NewEmptyFakeCache()
+			wd := buildWorkingDir(wdComponents{
+				fs:     fs,
+				git:    git,
+				cache:  cache,
+				config: &config,
+			})
+
+			err := wd.Go([]string{test.project}, []string{}, "", GoOpts{})
+			require.NoError(t, err)
+			_, ok := fs.repos[test.dir]
+			require.True(t, ok, "must be cloned to %s", test.dir)
+			info := test.info
+			info.URL, info.Branch, info.ClonedAt = test.url, "main", cloned
+			require.Equal(t, info, cache.data[path.Base(test.dir)])
+
+			results, err := wd.Done([]string{test.project}, DoneOpts{})
+			require.NoError(t, err)
+			require.Equal(t, test.dir, results[0].Path)
+			require.Empty(t, fs.repos)
+		})
+	}
+
+	t.Run("known projects", func(t *testing.T) {
+		wd := buildWorkingDir(wdComponents{
+			cache:  NewFakeCache(map[string]ProjectInfo{"flask": {}, "web": {}}),
+			config: &config,
+		})
+		require.Equal(t, []string{"auth", "billing", "flask", "web"}, wd.KnownProjects())
+	})
+}
+
+func TestGoCloneOpts(t *testing.T) {
+	fs := NewFakeFS()
+	git := NewFakeGit(fs).WithSources([]string{"s1/p1", "s2/p2"})
+	config := NewDefaultConfig()
+	config.Clone = CloneOpts{Depth: 1}
+	config.SourceSettings = map[string]SourceSettings{
+		"s2": {Clone: CloneOpts{Filter: "blob:none", Depth: 10}},
+	}
+	wd := buildWorkingDir(wdComponents{
+		fs:     fs,
+		git:    git,
+		config: &config,
+	})
+
+	err := wd.Go([]string{"p1", "p2"}, []string{"s1", "s2"}, "", GoOpts{Clone: CloneOpts{NoTags: true}})
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		map[string]CloneOpts{
+			"/dwd/p1": {Depth: 1, NoTags: true},
+			"/dwd/p2": {Depth: 10, Filter: "blob:none", NoTags: true},
+		},
+		git.clones,
+	)
+}
+
+func TestHydrate(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		fs := NewFakeFS().WithRepos(
+			map[string]*FakeRepo{"/dwd/proj": {path: "/dwd/proj"}},
+		)
+		git := NewFakeGit(fs)
+		wd := buildWorkingDir(wdComponents{
+			fs:  fs,
+			git: git,
+		})
+
+		err := wd.Hydrate([]string{"proj"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"/dwd/proj"}, git.hydrated)
+	})
+	t.Run("does not exist", func(t *testing.T) {
+		fs := NewFakeFS()
+		git := NewFakeGit(fs)
+		wd := buildWorkingDir(wdComponents{
+			fs:  fs,
+			git: git,
+		})
+
+		err := wd.Hydrate([]string{"proj"})
+		require.Error(t, err)
+		require.Empty(t, git.hydrated)
 	})
 }
 
+func TestRunParallel(t *testing.T) {
+	tests := map[string]struct {
+		jobs        int
+		maxExpected int32
+	}{
+		"bounded":   {jobs: 2, maxExpected: 2},
+		"unbounded": {jobs: 0, maxExpected: 10},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var running, maxRunning int32
+			done := make([]bool, 10)
+			runParallel(10, test.jobs, func(i int) {
+				current := atomic.AddInt32(&running, 1)
+				for {
+					prev := atomic.LoadInt32(&maxRunning)
+					if current <= prev || atomic.CompareAndSwapInt32(&maxRunning, prev, current) {
+						break
+					}
+				}
+				done[i] = true
+				atomic.AddInt32(&running, -1)
+			})
+
+			require.LessOrEqual(t, maxRunning, test.maxExpected)
+			require.NotContains(t, done, false)
+		})
+	}
+}
+
 func TestDone(t *testing.T) {
 	t.Run("specific projects; forcedly removed", func(t *testing.T) {
 		tests := map[string]struct {
@@ -275,7 +975,7 @@ func TestDone(t *testing.T) {
 			},
 			"dirty": {
 				gitProjectState: GitProjectState{
-					Stashes: "stashes",
+					Stashes: []GitStash{{Ref: "stash@{0}"}},
 				},
 			},
 		}
@@ -284,7 +984,11 @@ func TestDone(t *testing.T) {
 			t.Run(
 				name,
 				func(t *testing.T) {
-					fs := NewFakeFS()
+					fs := NewFakeFS().WithRepos(
+						map[string]*FakeRepo{
+							"/dwd/proj": {path: "/dwd/proj"},
+						},
+					)
 					git := NewFakeGit(fs).WithStates(
 						map[string]GitProjectState{
 							"/dwd/proj": test.gitProjectState,
@@ -297,9 +1001,14 @@ func TestDone(t *testing.T) {
 						},
 					)
 
-					err := wd.Done([]string{"proj"}, DoneOpts{Force: true})
+					results, err := wd.Done([]string{"proj"}, DoneOpts{Force: true})
 					require.NoError(t, err)
 					require.Len(t, fs.repos, 0)
+					require.Equal(
+						t,
+						[]DoneResult{{Project: "proj", Path: "/dwd/proj", Outcome: DoneRemoved}},
+						results,
+					)
 				},
 			)
 		}
@@ -317,20 +1026,29 @@ func TestDone(t *testing.T) {
 				git: NewFakeGit(fs),
 			},
 		)
-		err := wd.Done([]string{"proj", "proj2"}, DoneOpts{})
+		results, err := wd.Done([]string{"proj", "proj2"}, DoneOpts{})
 		require.NoError(t, err)
 		require.Len(t, fs.repos, 0)
+		require.Equal(
+			t,
+			[]DoneResult{
+				{Project: "proj", Path: "/dwd/proj", Outcome: DoneRemoved},
+				{Project: "proj2", Path: "/dwd/proj2", Outcome: DoneRemoved},
+			},
+			results,
+		)
 	})
 	t.Run("specific projects; not clean; not removed", func(t *testing.T) {
 		fs := NewFakeFS().WithRepos(
 			map[string]*FakeRepo{
-				"/dwd/proj": {path: "/dwd/proj"},
+				"/dwd/proj":  {path: "/dwd/proj"},
+				"/dwd/proj2": {path: "/dwd/proj2"},
 			},
 		)
 		git := NewFakeGit(fs).WithStates(
 			map[string]GitProjectState{
 				"/dwd/proj": {
-					Status: "dirty",
+					Changes: dirtyChanges,
 				},
 			},
 		)
@@ -340,14 +1058,61 @@ func TestDone(t *testing.T) {
 				git: git,
 			},
 		)
-		err := wd.Done([]string{"proj", "proj2"}, DoneOpts{})
-		require.NoError(t, err)
+		results, err := wd.Done([]string{"proj", "proj2"}, DoneOpts{})
+		require.Error(t, err)
 		require.Len(t, fs.repos, 1)
+		require.Equal(
+			t,
+			[]DoneResult{
+				{
+					Project: "proj",
+					Path:    "/dwd/proj",
+					Outcome: DoneKeptDirty,
+					Reason:  "the project is not clean",
+					State:   GitProjectState{Changes: dirtyChanges},
+				},
+				{Project: "proj2", Path: "/dwd/proj2", Outcome: DoneRemoved},
+			},
+			results,
+		)
 	})
-	t.Run("all projects", func(t *testing.T) {
+	t.Run("specific projects; state error; not removed", func(t *testing.T) {
 		fs := NewFakeFS().WithRepos(
 			map[string]*FakeRepo{
-				"/dwd/proj":  {path: "/dwd/proj"},
+				"/dwd/proj": {path: "/dwd/proj"},
+			},
+		)
+		git := NewFakeGit(fs).WithStateErrors(
+			map[string]error{
+				"/dwd/proj": errors.New("not a git repository"),
+			},
+		)
+		wd := buildWorkingDir(
+			wdComponents{
+				fs:  fs,
+				git: git,
+			},
+		)
+		results, err := wd.Done([]string{"proj"}, DoneOpts{})
+		require.ErrorContains(t, err, "not a git repository")
+		require.Len(t, fs.repos, 1)
+		require.Equal(
+			t,
+			[]DoneResult{
+				{
+					Project: "proj",
+					Path:    "/dwd/proj",
+					Outcome: DoneError,
+					Reason:  "not a git repository",
+				},
+			},
+			results,
+		)
+	})
+	t.Run("all projects", func(t *testing.T) {
+		fs := NewFakeFS().WithRepos(
+			map[string]*FakeRepo{
+				"/dwd/proj":  {path: "/dwd/proj"},
 				"/dwd/proj2": {path: "/dwd/proj2"},
 			},
 		)
@@ -358,8 +1123,686 @@ func TestDone(t *testing.T) {
 				git: git,
 			},
 		)
-		err := wd.Done([]string{}, DoneOpts{})
+		results, err := wd.Done([]string{}, DoneOpts{})
 		require.NoError(t, err)
 		require.Len(t, fs.repos, 0)
+		require.Len(t, results, 2)
+	})
+}
+
+func TestDonePolicy(t *testing.T) {
+	state := GitProjectState{
+		Tags:    []string{"v1"},
+		Changes: []GitFileChange{{Status: "??", Path: "notes.swp"}},
+	}
+	config := NewDefaultConfig()
+	config.Done = DonePolicy{IgnoreUntracked: []string{"*.swp"}}
+	config.ProjectSettings = map[string]ProjectSettings{
+		"proj": {Done: DonePolicy{IgnoreTags: true}},
+	}
+
+	fs := NewFakeFS().WithRepos(
+		map[string]*FakeRepo{
+			"/dwd/proj":  {path: "/dwd/proj"},
+			"/dwd/proj2": {path: "/dwd/proj2"},
+		},
+	)
+	git := NewFakeGit(fs).WithStates(
+		map[string]GitProjectState{
+			"/dwd/proj":  state,
+			"/dwd/proj2": state,
+		},
+	)
+	wd := buildWorkingDir(
+		wdComponents{
+			fs:     fs,
+			git:    git,
+			config: &config,
+		},
+	)
+
+	results, err := wd.Done([]string{"proj", "proj2"}, DoneOpts{})
+	require.Error(t, err)
+	require.Len(t, fs.repos, 1)
+	require.Equal(
+		t,
+		[]DoneResult{
+			{
+				Project: "proj",
+				Path:    "/dwd/proj",
+				Outcome: DoneRemoved,
+				Ignored: []IgnoredItem{
+					{Item: "tag v1", Reason: "unpushed tags are ignored"},
+					{Item: "untracked notes.swp", Reason: "matches \"*.swp\""},
+				},
+			},
+			{
+				Project: "proj2",
+				Path:    "/dwd/proj2",
+				Outcome: DoneKeptDirty,
+				Reason:  "the project is not clean",
+				State:   GitProjectState{Tags: []string{"v1"}},
+				Ignored: []IgnoredItem{
+					{Item: "untracked notes.swp", Reason: "matches \"*.swp\""},
+				},
+			},
+		},
+		results,
+	)
+}
+
+func TestDoneDetectMerged(t *testing.T) {
+	commits := []GitCommit{
+		{Hash: "aaaaaaa1", Branch: "feature", Subject: "squashed"},
+		{Hash: "bbbbbbb1", Branch: "wip", Subject: "local"},
+	}
+	tests := map[string]struct {
+		merged          []string
+		expectedOutcome DoneOutcome
+	}{
+		"all merged": {
+			merged:          []string{"aaaaaaa1", "bbbbbbb1"},
+			expectedOutcome: DoneRemoved,
+		},
+		"partially merged": {
+			merged:          []string{"aaaaaaa1"},
+			expectedOutcome: DoneKeptDirty,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fs := NewFakeFS().WithRepos(map[string]*FakeRepo{"/dwd/proj": {path: "/dwd/proj"}})
+			git := NewFakeGit(fs).
+				WithStates(map[string]GitProjectState{"/dwd/proj": {Commits: commits}}).
+				WithMerged(test.merged)
+			wd := buildWorkingDir(wdComponents{fs: fs, git: git})
+
+			results, _ := wd.Done([]string{"proj"}, DoneOpts{DetectMerged: true})
+			require.Len(t, results, 1)
+			require.Equal(t, test.expectedOutcome, results[0].Outcome)
+			require.Contains(
+				t,
+				results[0].Ignored,
+				IgnoredItem{Item: "commit aaaaaaa (feature) squashed", Reason: "merged upstream"},
+			)
+		})
+	}
+	t.Run("not detected by default", func(t *testing.T) {
+		fs := NewFakeFS().WithRepos(map[string]*FakeRepo{"/dwd/proj": {path: "/dwd/proj"}})
+		git := NewFakeGit(fs).
+			WithStates(map[string]GitProjectState{"/dwd/proj": {Commits: commits}}).
+			WithMerged([]string{"aaaaaaa1", "bbbbbbb1"})
+		wd := buildWorkingDir(wdComponents{fs: fs, git: git})
+
+		results, err := wd.Done([]string{"proj"}, DoneOpts{})
+		require.Error(t, err)
+		require.Equal(t, DoneKeptDirty, results[0].Outcome)
+	})
+}
+
+func TestDoneTrash(t *testing.T) {
+	t.Run("clean; moved to trash", func(t *testing.T) {
+		fs := NewFakeFS().WithRepos(
+			map[string]*FakeRepo{"/dwd/proj": {path: "/dwd/proj"}},
+		)
+		trashDir := t.TempDir()
+		trash := NewTrash(trashDir, fs)
+		wd := buildWorkingDir(
+			wdComponents{
+				fs:    fs,
+				git:   NewFakeGit(fs),
+				cache: NewFakeCache(map[string]ProjectInfo{"proj": {Source: "s"}}),
+				trash: trash,
+			},
+		)
+
+		results, err := wd.Done([]string{"proj"}, DoneOpts{})
+		require.NoError(t, err)
+		require.Equal(t, DoneRemoved, results[0].Outcome)
+		require.NotEmpty(t, results[0].TrashID)
+
+		entries, err := trash.List()
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.Equal(t, "proj", entries[0].Project)
+		require.Equal(t, "/dwd/proj", entries[0].OriginalPath)
+		require.Equal(t, "s", entries[0].Source)
+
+		_, ok := fs.repos[path.Join(trashDir, results[0].TrashID)]
+		require.True(t, ok, "must be moved to the trash")
+	})
+	t.Run("restored", func(t *testing.T) {
+		fs := NewFakeFS().WithRepos(
+			map[string]*FakeRepo{"/dwd/proj": {path: "/dwd/proj"}},
+		)
+		trash := NewTrash(t.TempDir(), fs)
+		cache := NewFakeCache(map[string]ProjectInfo{"proj": {Source: "s"}})
+		wd := buildWorkingDir(
+			wdComponents{
+				fs:    fs,
+				git:   NewFakeGit(fs),
+				cache: cache,
+				trash: trash,
+			},
+		)
+		_, err := wd.Done([]string{"proj"}, DoneOpts{})
+		require.NoError(t, err)
+		delete(cache.data, "proj")
+
+		entry, err := wd.Restore("proj")
+		require.NoError(t, err)
+		require.Equal(t, "proj", entry.Project)
+		_, ok := fs.repos["/dwd/proj"]
+		require.True(t, ok, "must be restored")
+		require.Equal(t, ProjectInfo{Source: "s"}, cache.data["proj"])
+
+		entries, err := trash.List()
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+	t.Run("restored; cache write failed", func(t *testing.T) {
+		fs := NewFakeFS().WithRepos(
+			map[string]*FakeRepo{"/dwd/proj": {path: "/dwd/proj"}},
+		)
+		cache := NewFakeCache(map[string]ProjectInfo{"proj": {Source: "s"}})
+		wd := buildWorkingDir(
+			wdComponents{
+				fs:    fs,
+				git:   NewFakeGit(fs),
+				cache: cache,
+				trash: NewTrash(t.TempDir(), fs),
+			},
+		)
+		_, err := wd.Done([]string{"proj"}, DoneOpts{})
+		require.NoError(t, err)
+		delete(cache.data, "proj")
+		cache.WriteErr = errors.New("lock timeout")
+
+		_, err = wd.Restore("proj")
+		require.ErrorContains(t, err, "failed to remember its source: lock timeout")
+		require.Contains(t, fs.repos, "/dwd/proj")
+	})
+	t.Run("restore; destination exists", func(t *testing.T) {
+		fs := NewFakeFS().WithRepos(
+			map[string]*FakeRepo{"/dwd/proj": {path: "/dwd/proj"}},
+		)
+		trash := NewTrash(t.TempDir(), fs)
+		wd := buildWorkingDir(
+			wdComponents{
+				fs:    fs,
+				git:   NewFakeGit(fs),
+				trash: trash,
+			},
+		)
+		_, err := wd.Done([]string{"proj"}, DoneOpts{})
+		require.NoError(t, err)
+		fs.addRepo("/dwd/proj")
+
+		_, err = wd.Restore("proj")
+		require.Error(t, err)
+		entries, err := trash.List()
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+	})
+	t.Run("restore; not in trash", func(t *testing.T) {
+		fs := NewFakeFS()
+		wd := buildWorkingDir(
+			wdComponents{
+				fs:    fs,
+				git:   NewFakeGit(fs),
+				trash: NewTrash(t.TempDir(), fs),
+			},
+		)
+		_, err := wd.Restore("proj")
+		require.Error(t, err)
+	})
+}
+
+func TestDoneDryRun(t *testing.T) {
+	fs := NewFakeFS().WithRepos(
+		map[string]*FakeRepo{
+			"/dwd/proj":  {path: "/dwd/proj"},
+			"/dwd/proj2": {path: "/dwd/proj2"},
+		},
+	)
+	git := NewFakeGit(fs).WithStates(
+		map[string]GitProjectState{
+			"/dwd/proj2": {Changes: dirtyChanges},
+		},
+	)
+	wd := buildWorkingDir(
+		wdComponents{
+			fs:  fs,
+			git: git,
+		},
+	)
+
+	t.Run("not forced", func(t *testing.T) {
+		results, err := wd.Done([]string{"proj", "proj2"}, DoneOpts{DryRun: true})
+		require.Error(t, err)
+		require.Len(t, fs.repos, 2)
+		require.Equal(
+			t,
+			[]DoneResult{
+				{Project: "proj", Path: "/dwd/proj", Outcome: DoneWouldRemove},
+				{
+					Project: "proj2",
+					Path:    "/dwd/proj2",
+					Outcome: DoneKeptDirty,
+					Reason:  "the project is not clean",
+					State:   GitProjectState{Changes: dirtyChanges},
+				},
+			},
+			results,
+		)
+	})
+	t.Run("forced", func(t *testing.T) {
+		results, err := wd.Done([]string{"proj2"}, DoneOpts{DryRun: true, Force: true})
+		require.NoError(t, err)
+		require.Len(t, fs.repos, 2)
+		require.Equal(
+			t,
+			[]DoneResult{
+				{
+					Project: "proj2",
+					Path:    "/dwd/proj2",
+					Outcome: DoneWouldRemove,
+					State:   GitProjectState{Changes: dirtyChanges},
+				},
+			},
+			results,
+		)
+	})
+}
+
+func TestGoWorktree(t *testing.T) {
+	t.Run("cloned and added", func(t *testing.T) {
+		fs := NewFakeFS()
+		git := NewFakeGit(fs).WithSources([]string{"dsource/proj"})
+		wd := buildWorkingDir(wdComponents{fs: fs, git: git})
+
+		err := wd.Go([]string{"proj"}, []string{"dsource"}, "", GoOpts{Open: true, Worktree: "feature/x"})
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"/dwd/proj-feature-x": "feature/x"}, git.addedWorktrees)
+		require.Contains(t, fs.repos, "/dwd/proj")
+		require.Equal(t, "/dwd/proj-feature-x", fs.repos["/dwd/proj-feature-x"].path)
+		require.Equal(t, 1, fs.repos["/dwd/proj-feature-x"].opensCount)
+	})
+	t.Run("worktree exists", func(t *testing.T) {
+		fs := NewFakeFS().WithRepos(
+			map[string]*FakeRepo{
+				"/dwd/proj":   {path: "/dwd/proj"},
+				"/dwd/proj-x": {path: "/dwd/proj-x"},
+			},
+		)
+		git := NewFakeGit(fs)
+		wd := buildWorkingDir(wdComponents{fs: fs, git: git})
+
+		err := wd.Go([]string{"proj"}, []string{"dsource"}, "", GoOpts{Worktree: "x"})
+		require.NoError(t, err)
+		require.Empty(t, git.addedWorktrees)
+	})
+	t.Run("plan", func(t *testing.T) {
+		fs := NewFakeFS().WithRepos(map[string]*FakeRepo{"/dwd/proj": {path: "/dwd/proj"}})
+		wd := buildWorkingDir(wdComponents{fs: fs, git: NewFakeGit(fs)})
+
+		plan, err := wd.PlanGo([]string{"proj"}, []string{"dsource"}, "", GoOpts{Open: true, Worktree: "x"})
+		require.NoError(t, err)
+		require.Equal(t, "/dwd/proj-x", plan.Projects[0].Worktree)
+		require.Equal(t, "/dwd/proj-x", plan.OpenPath)
+	})
+}
+
+func TestDoneWorktrees(t *testing.T) {
+	worktrees := []GitWorktree{
+		{Path: "/dwd/proj", Branch: "main", Main: true},
+		{Path: "/dwd/proj-x", Branch: "x"},
+		{Path: "/elsewhere/proj-gone", Branch: "gone", Prunable: true},
+	}
+	current := func(path string) []GitWorktree {
+		marked := slices.Clone(worktrees)
+		for i := range marked {
+			marked[i].Current = marked[i].Path == path
+		}
+		return marked
+	}
+
+	t.Run("main with linked worktrees; kept", func(t *testing.T) {
+		fs := NewFakeFS().WithRepos(map[string]*FakeRepo{"/dwd/proj": {path: "/dwd/proj"}})
+		git := NewFakeGit(fs).WithWorktrees(map[string][]GitWorktree{"/dwd/proj": current("/dwd/proj")})
+		wd := buildWorkingDir(wdComponents{fs: fs, git: git})
+
+		results, err := wd.Done([]string{"proj"}, DoneOpts{})
+		require.Error(t, err)
+		require.Len(t, fs.repos, 1)
+		require.Equal(
+			t,
+			[]DoneResult{
+				{
+					Project: "proj",
+					Path:    "/dwd/proj",
+					Outcome: DoneKeptWorktrees,
+					Reason:  "the project has linked worktrees: /dwd/proj-x",
+				},
+			},
+			results,
+		)
+	})
+	t.Run("linked worktree; removed by git, not trashed", func(t *testing.T) {
+		fs := NewFakeFS().WithRepos(
+			map[string]*FakeRepo{
+				"/dwd/proj":   {path: "/dwd/proj"},
+				"/dwd/proj-x": {path: "/dwd/proj-x"},
+			},
+		)
+		git := NewFakeGit(fs).WithWorktrees(map[string][]GitWorktree{"/dwd/proj-x": current("/dwd/proj-x")})
+		trash := NewTrash(t.TempDir(), fs)
+		wd := buildWorkingDir(wdComponents{fs: fs, git: git, trash: trash})
+
+		results, err := wd.Done([]string{"proj-x"}, DoneOpts{})
+		require.NoError(t, err)
+		require.Equal(t, DoneRemoved, results[0].Outcome)
+		require.Empty(t, results[0].TrashID)
+		require.NotContains(t, fs.repos, "/dwd/proj-x")
+		require.Contains(t, fs.repos, "/dwd/proj")
+		require.Equal(t, []string{"/dwd/proj:/dwd/proj-x"}, git.removedTrees)
+		entries, err := trash.List()
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+	t.Run("linked worktree; dirty main repository; removed", func(t *testing.T) {
+		fs := NewFakeFS().WithRepos(map[string]*FakeRepo{"/dwd/proj-x": {path: "/dwd/proj-x"}})
+		// the shared repository reports the unpushed commits of other branches in every worktree
+		unpushed := GitProjectState{
+			Commits: []GitCommit{{Hash: "abc", Branch: "feat", Subject: "wip"}},
+			Stashes: []GitStash{{Ref: "stash@{0}"}},
+		}
+		git := NewFakeGit(fs).
+			WithWorktrees(map[string][]GitWorktree{"/dwd/proj-x": current("/dwd/proj-x")}).
+			WithStates(map[string]GitProjectState{"/dwd/proj": unpushed, "/dwd/proj-x": unpushed})
+		wd := buildWorkingDir(wdComponents{fs: fs, git: git})
+
+		results, err := wd.Done([]string{"proj-x"}, DoneOpts{DetectMerged: true})
+		require.NoError(t, err)
+		require.Equal(t, DoneRemoved, results[0].Outcome)
+		require.NotContains(t, fs.repos, "/dwd/proj-x")
+	})
+	t.Run("linked worktree; dirty; kept", func(t *testing.T) {
+		fs := NewFakeFS().WithRepos(map[string]*FakeRepo{"/dwd/proj-x": {path: "/dwd/proj-x"}})
+		git := NewFakeGit(fs).
+			WithWorktrees(map[string][]GitWorktree{"/dwd/proj-x": current("/dwd/proj-x")}).
+			WithWorktreeStates(map[string]GitProjectState{
+				"/dwd/proj-x": {Commits: []GitCommit{{Hash: "def", Branch: "HEAD", Subject: "detached"}}},
+			})
+		wd := buildWorkingDir(wdComponents{fs: fs, git: git})
+
+		results, err := wd.Done([]string{"proj-x"}, DoneOpts{})
+		require.Error(t, err)
+		require.Equal(t, DoneKeptDirty, results[0].Outcome)
+		require.Contains(t, fs.repos, "/dwd/proj-x")
+	})
+	t.Run("linked worktree; dry run; not removed", func(t *testing.T) {
+		fs := NewFakeFS().WithRepos(map[string]*FakeRepo{"/dwd/proj-x": {path: "/dwd/proj-x"}})
+		git := NewFakeGit(fs).WithWorktrees(map[string][]GitWorktree{"/dwd/proj-x": current("/dwd/proj-x")})
+		wd := buildWorkingDir(wdComponents{fs: fs, git: git})
+
+		results, err := wd.Done([]string{"proj-x"}, DoneOpts{DryRun: true})
+		require.NoError(t, err)
+		require.Equal(t, DoneWouldRemove, results[0].Outcome)
+		require.Empty(t, git.removedTrees)
+	})
+}
+
+func TestSync(t *testing.T) {
+	fs := NewFakeFS().WithRepos(
+		map[string]*FakeRepo{
+			"/dwd/behind":   {path: "/dwd/behind"},
+			"/dwd/current":  {path: "/dwd/current"},
+			"/dwd/diverged": {path: "/dwd/diverged"},
+			"/dwd/dirty":    {path: "/dwd/dirty"},
+			"/dwd/local":    {path: "/dwd/local"},
+			"/dwd/offline":  {path: "/dwd/offline"},
+		},
+	)
+	git := NewFakeGit(fs).
+		WithBranchStatuses(
+			map[string]GitBranchStatus{
+				"/dwd/behind":   {Branch: "main", Upstream: "origin/main", Behind: 2, Untracked: 1},
+				"/dwd/current":  {Branch: "main", Upstream: "origin/main", Ahead: 1},
+				"/dwd/diverged": {Branch: "dev", Upstream: "origin/dev", Ahead: 1, Behind: 3},
+				"/dwd/dirty":    {Branch: "main", Upstream: "origin/main", Behind: 1, Modified: 1},
+				"/dwd/local":    {Branch: "wip"},
+			},
+		).
+		WithFetchErrors(map[string]error{"/dwd/offline": errors.New("could not resolve host")})
+	wd := buildWorkingDir(wdComponents{fs: fs, git: git})
+
+	results, err := wd.Sync(nil, SyncOpts{})
+	require.ErrorContains(t, err, "could not resolve host")
+	require.Equal(
+		t,
+		[]SyncResult{
+			{Project: "behind", Branch: "main", Outcome: SyncUpdated, Behind: 2},
+			{Project: "current", Branch: "main", Outcome: SyncUpToDate, Ahead: 1},
+			{
+				Project: "dirty",
+				Branch:  "main",
+				Outcome: SyncSkippedDirty,
+				Behind:  1,
+				Reason:  "the project has local changes",
+			},
+			{Project: "diverged", Branch: "dev", Outcome: SyncDiverged, Ahead: 1, Behind: 3},
+			{Project: "local", Branch: "wip", Outcome: SyncNoUpstream},
+			{Project: "offline", Outcome: SyncError, Reason: "could not resolve host"},
+		},
+		results,
+	)
+	require.Equal(t, []string{"/dwd/behind"}, git.fastForwarded)
+
+	t.Run("specific project with default branch", func(t *testing.T) {
+		results, err := wd.Sync([]string{"current"}, SyncOpts{DefaultBranch: true})
+		require.NoError(t, err)
+		require.Equal(
+			t,
+			[]SyncResult{
+				{
+					Project:              "current",
+					Branch:               "main",
+					Outcome:              SyncUpToDate,
+					Ahead:                1,
+					DefaultBranch:        "main",
+					DefaultBranchUpdated: true,
+				},
+			},
+			results,
+		)
+	})
+	t.Run("diverged default branch; current branch synced", func(t *testing.T) {
+		git.WithDefaultBranchUpdates(
+			map[string]GitDefaultBranchUpdate{"/dwd/behind": {Branch: "main", Diverged: true}},
+		)
+		git.fastForwarded = nil
+
+		results, err := wd.Sync([]string{"behind"}, SyncOpts{DefaultBranch: true})
+		require.NoError(t, err)
+		require.Equal(
+			t,
+			[]SyncResult{
+				{
+					Project:               "behind",
+					Branch:                "main",
+					Outcome:               SyncUpdated,
+					Behind:                2,
+					DefaultBranch:         "main",
+					DefaultBranchDiverged: true,
+				},
+			},
+			results,
+		)
+		require.Equal(t, []string{"/dwd/behind"}, git.fastForwarded)
+	})
+}
+
+func TestPlanGo(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		fs := NewFakeFS().WithRepos(
+			map[string]*FakeRepo{"/dwd/p1": {path: "/dwd/p1"}},
+		)
+		cache := NewFakeCache(map[string]ProjectInfo{"p2": {Source: "sc"}})
+		wd := buildWorkingDir(wdComponents{
+			fs:    fs,
+			git:   NewFakeGit(fs),
+			cache: cache,
+		})
+
+		plan, err := wd.PlanGo([]string{"p1", "p2"}, []string{"s"}, "vim", GoOpts{Open: true})
+		require.NoError(t, err)
+		require.Equal(
+			t,
+			[]ProjectPlan{
+				{Project: "p1", Path: "/dwd/p1", Exists: true},
+				{Project: "p2", Path: "/dwd/p2", URLs: []string{"s/p2", "sc/p2"}},
+			},
+			plan.Projects,
+		)
+		require.Equal(t, "vim", plan.Editors[0])
+		require.Equal(t, "/dwd/p2", plan.OpenPath)
+		require.Len(t, fs.repos, 1)
+		require.Equal(t, cache.Writes, 0)
+	})
+	t.Run("not opened", func(t *testing.T) {
+		fs := NewFakeFS()
+		wd := buildWorkingDir(wdComponents{
+			fs:  fs,
+			git: NewFakeGit(fs),
+		})
+
+		plan, err := wd.PlanGo([]string{"p1"}, []string{"s"}, "", GoOpts{})
+		require.NoError(t, err)
+		require.Empty(t, plan.Editors)
+		require.Empty(t, plan.OpenPath)
+	})
+	t.Run("sources are empty", func(t *testing.T) {
+		wd := buildWorkingDir(wdComponents{fs: NewFakeFS()})
+		_, err := wd.PlanGo([]string{"p1"}, []string{}, "", GoOpts{})
+		require.Error(t, err)
+	})
+}
+
+func TestList(t *testing.T) {
+	t.Run("mixed projects", func(t *testing.T) {
+		fs := NewFakeFS().WithRepos(
+			map[string]*FakeRepo{
+				"/dwd/proj2": {path: "/dwd/proj2"},
+				"/dwd/proj":  {path: "/dwd/proj"},
+			},
+		)
+		git := NewFakeGit(fs).WithStates(
+			map[string]GitProjectState{
+				"/dwd/proj2": {Changes: dirtyChanges},
+			},
+		).WithBranches(
+			map[string]string{"/dwd/proj2": "feature"},
+		)
+		cache := NewFakeCache(map[string]ProjectInfo{"proj": {Source: "s"}})
+		wd := buildWorkingDir(
+			wdComponents{
+				fs:    fs,
+				git:   git,
+				cache: cache,
+			},
+		)
+
+		summaries, err := wd.List()
+		require.NoError(t, err)
+		require.Equal(
+			t,
+			[]ProjectSummary{
+				{Name: "proj", Source: "s", Branch: "main", Clean: true},
+				{Name: "proj2", Branch: "feature", Clean: false},
+			},
+			summaries,
+		)
+	})
+	t.Run("no projects", func(t *testing.T) {
+		fs := NewFakeFS()
+		wd := buildWorkingDir(
+			wdComponents{
+				fs:  fs,
+				git: NewFakeGit(fs),
+			},
+		)
+
+		summaries, err := wd.List()
+		require.NoError(t, err)
+		require.Empty(t, summaries)
+	})
+}
+
+func TestStatus(t *testing.T) {
+	fs := NewFakeFS().WithRepos(
+		map[string]*FakeRepo{
+			"/dwd/proj":  {path: "/dwd/proj"},
+			"/dwd/proj2": {path: "/dwd/proj2"},
+		},
+	)
+	git := NewFakeGit(fs).WithStates(
+		map[string]GitProjectState{
+			"/dwd/proj": {Stashes: []GitStash{{Ref: "stash@{0}"}, {Ref: "stash@{1}"}}, Tags: []string{"v1"}},
+		},
+	).WithBranchStatuses(
+		map[string]GitBranchStatus{
+			"/dwd/proj": {
+				Branch:    "main",
+				Upstream:  "origin/main",
+				Ahead:     1,
+				Behind:    2,
+				Modified:  3,
+				Untracked: 4,
+			},
+			"/dwd/proj2": {Branch: "feature"},
+		},
+	)
+	wd := buildWorkingDir(
+		wdComponents{
+			fs:  fs,
+			git: git,
+		},
+	)
+
+	t.Run("all projects", func(t *testing.T) {
+		statuses, err := wd.Status([]string{})
+		require.NoError(t, err)
+		require.Equal(
+			t,
+			[]ProjectStatus{
+				{
+					Name: "proj",
+					GitBranchStatus: GitBranchStatus{
+						Branch:    "main",
+						Upstream:  "origin/main",
+						Ahead:     1,
+						Behind:    2,
+						Modified:  3,
+						Untracked: 4,
+					},
+					Stashes:      2,
+					UnpushedTags: 1,
+				},
+				{
+					Name:            "proj2",
+					GitBranchStatus: GitBranchStatus{Branch: "feature"},
+				},
+			},
+			statuses,
+		)
+	})
+	t.Run("specific projects; unknown one errors", func(t *testing.T) {
+		statuses, err := wd.Status([]string{"proj2", "unknown"})
+		require.NoError(t, err)
+		require.Len(t, statuses, 2)
+		require.NoError(t, statuses[0].Err)
+		require.Error(t, statuses[1].Err)
 	})
 }